@@ -0,0 +1,206 @@
+package gopanes
+
+import (
+	"github.com/nsf/termbox-go"
+)
+
+// a screen is where canvases draw, the terminal or an off-screen buffer
+type screen interface {
+	Size() (width, height int)
+	SetCell(x, y int, ch rune, fg, bg termbox.Attribute)
+	CellBuffer() []termbox.Cell
+	SetCursor(x, y int)
+	Clear()
+	Flush() error
+}
+
+// termboxScreen draws on the terminal
+type termboxScreen struct{}
+
+func (termboxScreen) Size() (width, height int) { return termbox.Size() }
+func (termboxScreen) SetCell(x, y int, ch rune, fg, bg termbox.Attribute) {
+	termbox.SetCell(x, y, ch, fg, bg)
+}
+func (termboxScreen) CellBuffer() []termbox.Cell { return termbox.CellBuffer() }
+func (termboxScreen) SetCursor(x, y int)         { termbox.SetCursor(x, y) }
+func (termboxScreen) Clear()                     { termbox.Clear(tcd, tcd) }
+func (termboxScreen) Flush() error               { return termbox.Flush() }
+
+// memoryScreen is an off-screen buffer of cells, which starts out blank
+type memoryScreen struct {
+	width  int
+	height int
+	cells  []termbox.Cell
+}
+
+func newMemoryScreen(width, height int) *memoryScreen {
+	ms := &memoryScreen{width: width, height: height, cells: make([]termbox.Cell, width*height)}
+	ms.Clear()
+	return ms
+}
+
+func (ms *memoryScreen) Size() (width, height int) { return ms.width, ms.height }
+
+// SetCell ignores cells off the screen, like termbox does
+func (ms *memoryScreen) SetCell(x, y int, ch rune, fg, bg termbox.Attribute) {
+	if x >= 0 && x < ms.width && y >= 0 && y < ms.height {
+		ms.cells[y*ms.width+x] = termbox.Cell{Ch: ch, Fg: fg, Bg: bg}
+	}
+}
+
+func (ms *memoryScreen) CellBuffer() []termbox.Cell { return ms.cells }
+func (ms *memoryScreen) SetCursor(x, y int)         {}
+func (ms *memoryScreen) Flush() error               { return nil }
+
+func (ms *memoryScreen) Clear() {
+	for idx := range ms.cells {
+		ms.cells[idx] = termbox.Cell{Ch: ' ', Fg: tcd, Bg: tcd}
+	}
+}
+
+// drawScreen is where new canvases draw. It's only changed while holding
+// termboxMutex.
+var drawScreen screen = termboxScreen{}
+
+// A Canvas is a rectangle of the screen to draw on. Coordinates are relative
+// to its top left corner and anything drawn outside of it is clipped, so
+// drawing code can't scribble over dividers or neighboring panes.
+type Canvas struct {
+	screen screen
+	x      int
+	y      int
+	width  int
+	height int
+}
+
+// NewCanvas returns a canvas for the given area of the screen
+func NewCanvas(x, y, width, height int) *Canvas {
+	return newCanvasOn(drawScreen, x, y, width, height)
+}
+
+// newCanvasOn returns a canvas for an area of the given screen
+func newCanvasOn(s screen, x, y, width, height int) *Canvas {
+	if width < 0 {
+		width = 0
+	}
+	if height < 0 {
+		height = 0
+	}
+	return &Canvas{screen: s, x: x, y: y, width: width, height: height}
+}
+
+func (c *Canvas) Width() int  { return c.width }
+func (c *Canvas) Height() int { return c.height }
+
+// Sub returns a canvas for an area of this one, clipped to it
+func (c *Canvas) Sub(x, y, width, height int) *Canvas {
+	if x < 0 {
+		width += x
+		x = 0
+	}
+	if y < 0 {
+		height += y
+		y = 0
+	}
+	if x+width > c.width {
+		width = c.width - x
+	}
+	if y+height > c.height {
+		height = c.height - y
+	}
+	return newCanvasOn(c.screen, c.x+x, c.y+y, width, height)
+}
+
+func (c *Canvas) contains(x, y int) bool {
+	return x >= 0 && x < c.width && y >= 0 && y < c.height
+}
+
+// SetCell draws a single rune, unless it's outside the canvas
+func (c *Canvas) SetCell(x, y int, ch rune, style Style) {
+	if c.contains(x, y) {
+		fg, bg := style.attributes()
+		c.screen.SetCell(c.x+x, c.y+y, ch, fg, bg)
+	}
+}
+
+// Fill fills an area of the canvas with a rune
+func (c *Canvas) Fill(x, y, width, height int, ch rune, style Style) {
+	fg, bg := style.attributes()
+	for row := y; row < y+height; row++ {
+		for col := x; col < x+width; col++ {
+			if c.contains(col, row) {
+				c.screen.SetCell(c.x+col, c.y+row, ch, fg, bg)
+			}
+		}
+	}
+}
+
+// Clear fills the whole canvas with spaces
+func (c *Canvas) Clear(style Style) {
+	c.Fill(0, 0, c.width, c.height, ' ', style)
+}
+
+// Print draws a string on one row, taking wide runes and tabs into account.
+// Runes that don't entirely fit are left out. It returns the number of
+// columns the string took up, including any that were clipped.
+func (c *Canvas) Print(x, y int, str string, style Style) int {
+	return c.PrintColorStrs(x, y, []ColorStr{style.Str(str)})
+}
+
+// PrintColorStrs is Print for multicolored text
+func (c *Canvas) PrintColorStrs(x, y int, colorStrs []ColorStr) int {
+	col := x
+	for _, colorStr := range colorStrs {
+		fg, bg := colorStr.Style.attributes()
+		for _, r := range colorStr.Str {
+			w := runeWidth(r, col-x)
+			if w == 0 {
+				continue
+			}
+			if r == '\t' {
+				r = ' '
+			}
+			if c.contains(col, y) && c.contains(col+w-1, y) {
+				c.screen.SetCell(c.x+col, c.y+y, r, fg, bg)
+				// tabs are several spaces, wide runes cover the cell after them
+				for i := 1; i < w; i++ {
+					c.screen.SetCell(c.x+col+i, c.y+y, ' ', fg, bg)
+				}
+			}
+			col += w
+		}
+	}
+	return col - x
+}
+
+// HLine draws a horizontal line of the given length
+func (c *Canvas) HLine(x, y, length int, style Style) {
+	c.Fill(x, y, length, 1, glyphs().horizontal, style)
+}
+
+// VLine draws a vertical line of the given length
+func (c *Canvas) VLine(x, y, length int, style Style) {
+	c.Fill(x, y, 1, length, glyphs().vertical, style)
+}
+
+// Box draws the outline of a rectangle
+func (c *Canvas) Box(x, y, width, height int, style Style) {
+	if width < 2 || height < 2 {
+		return
+	}
+	c.HLine(x+1, y, width-2, style)
+	c.HLine(x+1, y+height-1, width-2, style)
+	c.VLine(x, y+1, height-2, style)
+	c.VLine(x+width-1, y+1, height-2, style)
+	c.SetCell(x, y, glyphs().topLeft, style)
+	c.SetCell(x+width-1, y, glyphs().topRight, style)
+	c.SetCell(x, y+height-1, glyphs().bottomLeft, style)
+	c.SetCell(x+width-1, y+height-1, glyphs().bottomRight, style)
+}
+
+// SetCursor shows the terminal cursor at a position on the canvas
+func (c *Canvas) SetCursor(x, y int) {
+	if c.contains(x, y) {
+		c.screen.SetCursor(c.x+x, c.y+y)
+	}
+}
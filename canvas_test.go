@@ -0,0 +1,121 @@
+package gopanes
+
+import (
+	"reflect"
+	"testing"
+)
+
+// screenRows returns the runes of each row of the screen
+func screenRows(ms *memoryScreen) []string {
+	rows := make([]string, ms.height)
+	for y := range rows {
+		var row []rune
+		for _, cell := range ms.cells[y*ms.width : (y+1)*ms.width] {
+			row = append(row, cell.Ch)
+		}
+		rows[y] = string(row)
+	}
+	return rows
+}
+
+func TestCanvasClipping(t *testing.T) {
+	g := glyphs()
+	tests := []struct {
+		name string
+		draw func(c *Canvas)
+		want []string
+	}{
+		{"cells outside", func(c *Canvas) {
+			c.SetCell(-1, 0, 'x', NewStyle())
+			c.SetCell(4, 0, 'x', NewStyle())
+			c.SetCell(0, 3, 'x', NewStyle())
+			c.SetCell(3, 2, 'y', NewStyle())
+		}, []string{"      ", "      ", "    y "}},
+		{"fill", func(c *Canvas) {
+			c.Fill(-2, 1, 10, 5, '#', NewStyle())
+		}, []string{"      ", " #### ", " #### "}},
+		{"print", func(c *Canvas) {
+			c.Print(2, 0, "abcdef", NewStyle())
+			c.Print(-2, 1, "abcdef", NewStyle())
+		}, []string{"   ab ", " cdef ", "      "}},
+		{"wide runes at the edges", func(c *Canvas) {
+			c.Print(3, 0, "世", NewStyle())
+			c.Print(2, 1, "世", NewStyle())
+			c.Print(-1, 2, "世ab", NewStyle())
+		}, []string{"      ", "   世  ", "  ab  "}},
+		{"tabs", func(c *Canvas) {
+			c.Print(0, 0, "a\tb", NewStyle())
+		}, []string{" a    ", "      ", "      "}},
+		{"sub canvas", func(c *Canvas) {
+			c.Sub(2, 1, 10, 10).Fill(0, 0, 10, 10, '#', NewStyle())
+			c.Sub(-1, 0, 2, 1).Fill(0, 0, 2, 1, '-', NewStyle())
+		}, []string{" -    ", "   ## ", "   ## "}},
+		{"box", func(c *Canvas) {
+			c.Box(0, 0, 4, 3, NewStyle())
+		}, []string{
+			" " + string([]rune{g.topLeft, g.horizontal, g.horizontal, g.topRight}) + " ",
+			" " + string([]rune{g.vertical, ' ', ' ', g.vertical}) + " ",
+			" " + string([]rune{g.bottomLeft, g.horizontal, g.horizontal, g.bottomRight}) + " ",
+		}},
+		{"box too small", func(c *Canvas) {
+			c.Box(0, 0, 1, 3, NewStyle())
+		}, []string{"      ", "      ", "      "}},
+	}
+	for _, test := range tests {
+		ms := newMemoryScreen(6, 3)
+		test.draw(newCanvasOn(ms, 1, 0, 4, 3))
+		if got := screenRows(ms); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: rows = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestContentLogDrawRow(t *testing.T) {
+	ms := newMemoryScreen(12, 1)
+	cl := NewContentLog()
+	var row []ColorRune
+	for _, ch := range "a\t世b" {
+		row = append(row, ColorRune{Ch: ch, Style: NewStyle()})
+	}
+	cl.drawRow(newCanvasOn(ms, 0, 0, 12, 1), 0, row)
+	if got, want := screenRows(ms)[0], "a       世 b "; got != want {
+		t.Errorf("row = %q, want %q", got, want)
+	}
+}
+
+// newTestUi returns a UI drawing on an off-screen buffer until the test ends
+func newTestUi(t *testing.T, width, height int) (*GoPaneUi, *memoryScreen) {
+	ms := newMemoryScreen(width, height)
+	termboxMutex.Lock()
+	drawScreen = ms
+	termboxMutex.Unlock()
+	ui := &GoPaneUi{}
+	ui.Root = NewGoPane(width, height, 0, 0)
+	ui.Root.ui = ui
+	t.Cleanup(func() {
+		ui.SetStatusBar(nil)
+		termboxMutex.Lock()
+		drawScreen = termboxScreen{}
+		termboxMutex.Unlock()
+	})
+	return ui, ms
+}
+
+func TestContentLogDrawRowScrolled(t *testing.T) {
+	cl := NewContentLog()
+	cl.SetWrapMode(WrapNone)
+	cl.hScroll = 2
+	var row []ColorRune
+	for _, ch := range "\tab世" {
+		row = append(row, ColorRune{Ch: ch, Style: NewStyle()})
+	}
+	ms := newMemoryScreen(8, 1)
+	c := newCanvasOn(ms, 0, 0, 8, 1)
+	c.Fill(0, 0, 8, 1, 'x', NewStyle())
+	cl.drawRow(c, 0, row)
+	// the visible part of the tab is blank, and 世 doesn't fit
+	want := string([]rune{glyphs().leftArrow}) + "     a" + string([]rune{glyphs().rightArrow})
+	if got := screenRows(ms)[0]; got != want {
+		t.Errorf("row = %q, want %q", got, want)
+	}
+}
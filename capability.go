@@ -0,0 +1,224 @@
+package gopanes
+
+import (
+	"github.com/nsf/termbox-go"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ColorSupport is how many colors the terminal can show. Colors a terminal
+// can't show are downsampled to the nearest one it can.
+type ColorSupport int
+
+const (
+	ColorSupportAuto      ColorSupport = iota // detect from the environment
+	ColorSupportBasic                         // the 8 (or 16 with bold) ANSI colors
+	ColorSupport256                           // the xterm 256 color palette
+	ColorSupportTrueColor                     // 24-bit RGB
+)
+
+// DetectColorSupport guesses the terminal's color support from the TERM and
+// COLORTERM environment variables
+func DetectColorSupport() ColorSupport {
+	return detectColorSupport(os.Getenv("TERM"), os.Getenv("COLORTERM"))
+}
+
+func detectColorSupport(term, colorterm string) ColorSupport {
+	colorterm = strings.ToLower(colorterm)
+	if colorterm == "truecolor" || colorterm == "24bit" || strings.HasSuffix(term, "-direct") {
+		return ColorSupportTrueColor
+	}
+	if strings.Contains(term, "256color") {
+		return ColorSupport256
+	}
+	return ColorSupportBasic
+}
+
+func (cs ColorSupport) outputMode() termbox.OutputMode {
+	switch cs {
+	case ColorSupport256:
+		return termbox.Output256
+	case ColorSupportTrueColor:
+		return termbox.OutputRGB
+	}
+	return termbox.OutputNormal
+}
+
+func colorDistance(r1, g1, b1, r2, g2, b2 uint8) int {
+	dr := int(r1) - int(r2)
+	dg := int(g1) - int(g2)
+	db := int(b1) - int(b2)
+	return dr*dr + dg*dg + db*db
+}
+
+// nearestBasic returns the basic color closest to the given RGB value
+func nearestBasic(r, g, b uint8) TermColor {
+	best, bestDist := 0, -1
+	for idx, rgb := range basicPalette {
+		dist := colorDistance(r, g, b, rgb[0], rgb[1], rgb[2])
+		if bestDist < 0 || dist < bestDist {
+			best, bestDist = idx, dist
+		}
+	}
+	return colorKindBasic | TermColor(best)
+}
+
+// nearestCubeLevel returns the index of the color cube level closest to v
+func nearestCubeLevel(v uint8) int {
+	switch {
+	case v < 48:
+		return 0
+	case v < 115:
+		return 1
+	}
+	return int(v-35) / 40
+}
+
+// nearest256 returns the 256 palette color closest to the given RGB value,
+// looking at both the color cube and the grayscale ramp
+func nearest256(r, g, b uint8) TermColor {
+	ri, gi, bi := nearestCubeLevel(r), nearestCubeLevel(g), nearestCubeLevel(b)
+	cube := Color256(uint8(16 + 36*ri + 6*gi + bi))
+
+	avg := (int(r) + int(g) + int(b)) / 3
+	grayIdx := 23
+	if avg < 238 {
+		grayIdx = (avg - 3) / 10
+		if grayIdx < 0 {
+			grayIdx = 0
+		}
+	}
+	gray := Color256(uint8(232 + grayIdx))
+
+	cr, cg, cb := cube.RGB()
+	gr, gg, gb := gray.RGB()
+	if colorDistance(r, g, b, gr, gg, gb) < colorDistance(r, g, b, cr, cg, cb) {
+		return gray
+	}
+	return cube
+}
+
+// grayLevel returns the value of a shade of termbox's grayscale output mode:
+// black, the 256 color palette's grayscale ramp, then white
+func grayLevel(idx int) int {
+	switch idx {
+	case 0:
+		return 0
+	case 25:
+		return 255
+	}
+	return 8 + (idx-1)*10
+}
+
+// nearestGray returns the index of the grayscale mode shade closest to the
+// given RGB value
+func nearestGray(r, g, b uint8) int {
+	avg := (int(r) + int(g) + int(b)) / 3
+	best, bestDist := 0, -1
+	for idx := 0; idx < 26; idx++ {
+		dist := avg - grayLevel(idx)
+		if dist < 0 {
+			dist = -dist
+		}
+		if bestDist < 0 || dist < bestDist {
+			best, bestDist = idx, dist
+		}
+	}
+	return best
+}
+
+// glyphSet holds the characters used to draw borders and indicators, so they
+// can fall back to plain ASCII when the locale isn't UTF-8
+type glyphSet struct {
+	vertical   rune
+	horizontal rune
+	cross      rune
+	teeDown    rune
+	teeUp      rune
+	teeRight   rune
+	teeLeft    rune
+	rightArrow rune
+	leftArrow  rune
+	upArrow    rune
+	downArrow  rune
+	// ends truncated text
+	ellipsis rune
+	// the done and to do parts of progress bars
+	barFull  rune
+	barEmpty rune
+	// the frames of spinners
+	spinner string
+	// the markers of expanded and collapsed tree nodes
+	expanded  rune
+	collapsed rune
+	// eighths of a cell, filled from the bottom and from the left, for charts
+	vBlocks string
+	hBlocks string
+	// starts rows that continue a wrapped line
+	continuation rune
+	// box corners
+	topLeft     rune
+	topRight    rune
+	bottomLeft  rune
+	bottomRight rune
+}
+
+var unicodeGlyphs = glyphSet{
+	vertical: '│', horizontal: '─',
+	cross: '┼', teeDown: '┬', teeUp: '┴', teeRight: '├', teeLeft: '┤',
+	rightArrow: '→', leftArrow: '←', upArrow: '↑', downArrow: '↓',
+	ellipsis: '…', barFull: '█', barEmpty: '░',
+	spinner:  "⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏",
+	expanded: '▾', collapsed: '▸',
+	vBlocks: "▁▂▃▄▅▆▇█", hBlocks: "▏▎▍▌▋▊▉█",
+	continuation: '↪',
+	topLeft:      '┌', topRight: '┐', bottomLeft: '└', bottomRight: '┘',
+}
+var asciiGlyphs = glyphSet{
+	vertical: '|', horizontal: '-',
+	cross: '+', teeDown: '+', teeUp: '+', teeRight: '+', teeLeft: '+',
+	rightArrow: '>', leftArrow: '<', upArrow: '^', downArrow: 'v',
+	ellipsis: '~', barFull: '#', barEmpty: '-',
+	spinner:  `|/-\`,
+	expanded: '-', collapsed: '+',
+	vBlocks: "_.-~=+*#", hBlocks: "    ####",
+	continuation: '+',
+	topLeft:      '+', topRight: '+', bottomLeft: '+', bottomRight: '+',
+}
+
+// the glyphs currently in use and the default theme drawn with them, shared
+// by every UI in the process, see GoPaneUi.SetASCIIOnly
+var (
+	glyphsLock          sync.RWMutex
+	currentGlyphs       = &unicodeGlyphs
+	currentDefaultTheme = newDefaultTheme(&unicodeGlyphs)
+)
+
+// glyphs returns the glyphs currently in use
+func glyphs() *glyphSet {
+	glyphsLock.RLock()
+	defer glyphsLock.RUnlock()
+	return currentGlyphs
+}
+
+// setGlyphs switches the glyphs in use, and the default theme's dividers with
+// them
+func setGlyphs(g *glyphSet) {
+	theme := newDefaultTheme(g)
+	glyphsLock.Lock()
+	defer glyphsLock.Unlock()
+	currentGlyphs = g
+	currentDefaultTheme = theme
+}
+
+// localeIsUTF8 checks the locale variables in the order the C library does
+func localeIsUTF8() bool {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			value = strings.ToLower(value)
+			return strings.Contains(value, "utf-8") || strings.Contains(value, "utf8")
+		}
+	}
+	return false
+}
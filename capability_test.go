@@ -0,0 +1,48 @@
+package gopanes
+
+import (
+	"github.com/nsf/termbox-go"
+	"testing"
+)
+
+func TestDetectColorSupport(t *testing.T) {
+	tests := []struct {
+		term, colorterm string
+		want            ColorSupport
+	}{
+		{"xterm", "", ColorSupportBasic},
+		{"xterm-256color", "", ColorSupport256},
+		{"screen-256color", "truecolor", ColorSupportTrueColor},
+		{"xterm", "24bit", ColorSupportTrueColor},
+		{"xterm-direct", "", ColorSupportTrueColor},
+		{"", "", ColorSupportBasic},
+	}
+	for _, test := range tests {
+		if got := detectColorSupport(test.term, test.colorterm); got != test.want {
+			t.Errorf("TERM=%q COLORTERM=%q: got %v, want %v", test.term, test.colorterm, got, test.want)
+		}
+	}
+}
+
+func TestDownsampling(t *testing.T) {
+	tests := []struct {
+		color TermColor
+		mode  termbox.OutputMode
+		want  termbox.Attribute
+	}{
+		// exact cube and grayscale entries map back to themselves
+		{ColorRGB(255, 0, 0), termbox.Output256, 196 + 1},
+		{ColorRGB(95, 135, 175), termbox.Output256, 67 + 1},
+		{ColorRGB(128, 128, 128), termbox.Output256, 244 + 1},
+		// and everything maps onto the basic colors in normal mode
+		{ColorRGB(250, 10, 10), termbox.OutputNormal, termbox.ColorRed | termbox.AttrBold},
+		{ColorRGB(0, 0, 200), termbox.OutputNormal, termbox.ColorBlue},
+		{Color256(22), termbox.OutputNormal, termbox.ColorBlack},
+		{Color256(46), termbox.OutputNormal, termbox.ColorGreen | termbox.AttrBold},
+	}
+	for _, test := range tests {
+		if got := test.color.attribute(test.mode); got != test.want {
+			t.Errorf("%#x in mode %v: got %v, want %v", test.color, test.mode, got, test.want)
+		}
+	}
+}
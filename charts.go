@@ -0,0 +1,331 @@
+package gopanes
+
+import (
+	"github.com/mattn/go-runewidth"
+	"strconv"
+)
+
+// block returns the glyph filling the given number of eighths of a cell, or a
+// space for none
+func block(blocks string, eighths int) rune {
+	if eighths <= 0 {
+		return ' '
+	}
+	if eighths > 8 {
+		eighths = 8
+	}
+	return []rune(blocks)[eighths-1]
+}
+
+// scale maps v from lo..hi to 0..steps, clamping values outside the range
+func scale(v, lo, hi float64, steps int) int {
+	if hi <= lo {
+		return 0
+	}
+	scaled := int((v-lo)/(hi-lo)*float64(steps) + 0.5)
+	if scaled < 0 {
+		return 0
+	}
+	if scaled > steps {
+		return steps
+	}
+	return scaled
+}
+
+// Sparkline shows the most recent values of a series as a small chart, as
+// many values as the pane is wide, scaled to the pane's height
+type Sparkline struct {
+	indicator
+	values []float64
+
+	// how many values are kept, defaults to 512
+	MaxValues int
+	// the range of the chart; when Min and Max are equal the range of the
+	// visible values is used
+	Min   float64
+	Max   float64
+	Style Style
+}
+
+func NewSparkline() *Sparkline {
+	s := &Sparkline{MaxValues: 512, Style: NewStyle().Fg(ColorCyan)}
+	s.render = s.Line
+	return s
+}
+
+// ShowIn hosts the sparkline in the pane
+func (s *Sparkline) ShowIn(gp *GoPane) {
+	s.show(gp, s)
+}
+
+// Push appends values to the series, dropping the oldest ones past MaxValues
+func (s *Sparkline) Push(values ...float64) {
+	s.lock.Lock()
+	s.values = append(s.values, values...)
+	if s.MaxValues > 0 && len(s.values) > s.MaxValues {
+		s.values = append([]float64(nil), s.values[len(s.values)-s.MaxValues:]...)
+	}
+	s.lock.Unlock()
+	s.changed()
+}
+
+// SetValues replaces the series
+func (s *Sparkline) SetValues(values []float64) {
+	s.lock.Lock()
+	s.values = append([]float64(nil), values...)
+	s.lock.Unlock()
+	s.changed()
+}
+
+func (s *Sparkline) Values() []float64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]float64(nil), s.values...)
+}
+
+// levels returns how many eighths of a cell high each of the last width
+// values is in a chart of the given height. The caller must hold lock.
+func (s *Sparkline) levels(width, height int) []int {
+	values := s.values
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+	lo, hi := s.Min, s.Max
+	if lo == hi && len(values) > 0 {
+		lo, hi = values[0], values[0]
+		for _, v := range values {
+			if v < lo {
+				lo = v
+			}
+			if v > hi {
+				hi = v
+			}
+		}
+	}
+	levels := make([]int, len(values))
+	for i, v := range values {
+		// the lowest value still gets the smallest block, and a flat series
+		// sits in the middle
+		if hi <= lo {
+			levels[i] = height * 4
+		} else {
+			levels[i] = 1 + scale(v, lo, hi, height*8-1)
+		}
+	}
+	return levels
+}
+
+// Line renders the last width values as a single row
+func (s *Sparkline) Line(width int) []ColorStr {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var line []rune
+	for _, level := range s.levels(width, 1) {
+		line = append(line, block(glyphs().vBlocks, level))
+	}
+	return []ColorStr{s.Style.Str(string(line))}
+}
+
+// PreferredSize is one row for all the values
+func (s *Sparkline) PreferredSize() (width, height int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.values), 1
+}
+
+func (s *Sparkline) Draw(c *Canvas) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	c.Clear(NewStyle())
+	height := c.Height()
+	for x, level := range s.levels(c.Width(), height) {
+		for row := 0; row < height; row++ {
+			// rows count up from the bottom
+			c.SetCell(x, height-1-row, block(glyphs().vBlocks, level-row*8), s.Style)
+		}
+	}
+}
+
+type Bar struct {
+	Label string
+	Value float64
+	// the bar's style, defaults to the chart's BarStyle
+	Style Style
+}
+
+// BarChart shows labelled values as bars, scaled to fit the pane. Horizontal
+// charts have a row for each bar with its label and value, vertical ones a
+// column for each bar with its label underneath.
+type BarChart struct {
+	indicator
+	bars     []Bar
+	vertical bool
+
+	// the value of a full length bar; zero means the largest value
+	Max      float64
+	BarStyle Style
+	// the width of the bars of vertical charts, defaults to 3
+	BarWidth int
+}
+
+func NewBarChart(vertical bool) *BarChart {
+	bc := &BarChart{vertical: vertical, BarStyle: NewStyle().Fg(ColorBlue), BarWidth: 3}
+	bc.render = bc.Line
+	return bc
+}
+
+// ShowIn hosts the bar chart in the pane
+func (bc *BarChart) ShowIn(gp *GoPane) {
+	bc.show(gp, bc)
+}
+
+// SetBars replaces all the bars
+func (bc *BarChart) SetBars(bars []Bar) {
+	bc.lock.Lock()
+	bc.bars = append([]Bar(nil), bars...)
+	bc.lock.Unlock()
+	bc.changed()
+}
+
+// SetValue sets the value of the bar with the given label, adding a bar if
+// there isn't one
+func (bc *BarChart) SetValue(label string, value float64) {
+	bc.lock.Lock()
+	found := false
+	for i := range bc.bars {
+		if bc.bars[i].Label == label {
+			bc.bars[i].Value = value
+			found = true
+			break
+		}
+	}
+	if !found {
+		bc.bars = append(bc.bars, Bar{Label: label, Value: value})
+	}
+	bc.lock.Unlock()
+	bc.changed()
+}
+
+func (bc *BarChart) Bars() []Bar {
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+	return append([]Bar(nil), bc.bars...)
+}
+
+// the caller must hold lock
+func (bc *BarChart) max() float64 {
+	if bc.Max > 0 {
+		return bc.Max
+	}
+	max := 0.0
+	for _, bar := range bc.bars {
+		if bar.Value > max {
+			max = bar.Value
+		}
+	}
+	return max
+}
+
+// the caller must hold lock
+func (bc *BarChart) style(bar Bar) Style {
+	return inheritStyle(bar.Style, bc.BarStyle)
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
+
+// Line renders the chart as a single row with a block for each bar
+func (bc *BarChart) Line(width int) []ColorStr {
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+	var line []ColorStr
+	max := bc.max()
+	for i, bar := range bc.bars {
+		if i >= width {
+			break
+		}
+		ch := block(glyphs().vBlocks, scale(bar.Value, 0, max, 8))
+		line = append(line, bc.style(bar).Str(string(ch)))
+	}
+	return line
+}
+
+// PreferredSize fits every bar
+func (bc *BarChart) PreferredSize() (width, height int) {
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+	if bc.vertical {
+		return len(bc.bars) * (bc.BarWidth + 1), 0
+	}
+	return 0, len(bc.bars)
+}
+
+func (bc *BarChart) Draw(c *Canvas) {
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+	c.Clear(NewStyle())
+	if bc.vertical {
+		bc.drawVertical(c)
+	} else {
+		bc.drawHorizontal(c)
+	}
+}
+
+// the caller must hold lock
+func (bc *BarChart) drawHorizontal(c *Canvas) {
+	labelWidth, valueWidth := 0, 0
+	for _, bar := range bc.bars {
+		if w := runewidth.StringWidth(bar.Label); w > labelWidth {
+			labelWidth = w
+		}
+		if w := len(formatValue(bar.Value)); w > valueWidth {
+			valueWidth = w
+		}
+	}
+	// labels don't get more than a third of the width
+	if labelWidth > c.Width()/3 {
+		labelWidth = c.Width() / 3
+	}
+	barArea := c.Width() - labelWidth - valueWidth - 2
+	max := bc.max()
+	for y, bar := range bc.bars {
+		if y >= c.Height() {
+			break
+		}
+		c.Print(0, y, truncate(bar.Label, labelWidth), NewStyle())
+		eighths := scale(bar.Value, 0, max, barArea*8)
+		x := labelWidth + 1
+		for ; eighths > 0; eighths -= 8 {
+			c.SetCell(x, y, block(glyphs().hBlocks, eighths), bc.style(bar))
+			x++
+		}
+		c.Print(x+1, y, formatValue(bar.Value), NewStyle())
+	}
+}
+
+// the caller must hold lock
+func (bc *BarChart) drawVertical(c *Canvas) {
+	barWidth := bc.BarWidth
+	if barWidth < 1 {
+		barWidth = 1
+	}
+	// the bottom row has the labels and the top row the values
+	barArea := c.Height() - 2
+	max := bc.max()
+	for i, bar := range bc.bars {
+		x := i * (barWidth + 1)
+		if x >= c.Width() {
+			break
+		}
+		eighths := scale(bar.Value, 0, max, barArea*8)
+		top := barArea + 1
+		for row := 0; eighths-row*8 > 0; row++ {
+			ch := block(glyphs().vBlocks, eighths-row*8)
+			top = barArea - row
+			c.Fill(x, top, barWidth, 1, ch, bc.style(bar))
+		}
+		c.Print(x, top-1, truncate(formatValue(bar.Value), barWidth), NewStyle())
+		c.Print(x, c.Height()-1, truncate(bar.Label, barWidth), NewStyle())
+	}
+}
@@ -0,0 +1,42 @@
+package gopanes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSparklineLevels(t *testing.T) {
+	s := NewSparkline()
+	s.Push(0, 5, 10, 7)
+	// only the last 3 values fit, scaled between 5 and 10
+	if got, want := s.levels(3, 1), []int{1, 8, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("levels(3, 1) = %v, want %v", got, want)
+	}
+	if got, want := s.levels(4, 2), []int{1, 9, 16, 12}; !reflect.DeepEqual(got, want) {
+		t.Errorf("levels(4, 2) = %v, want %v", got, want)
+	}
+	s.Min, s.Max = 0, 20
+	if got, want := s.levels(2, 1), []int{5, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("levels with fixed range = %v, want %v", got, want)
+	}
+	s.SetValues([]float64{3, 3})
+	s.Min, s.Max = 0, 0
+	if got, want := s.levels(2, 1), []int{4, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("levels of a flat series = %v, want %v", got, want)
+	}
+}
+
+func TestBarChartLine(t *testing.T) {
+	bc := NewBarChart(false)
+	bc.SetValue("a", 2)
+	bc.SetValue("b", 8)
+	bc.SetValue("a", 4)
+	var got string
+	for _, colorStr := range bc.Line(10) {
+		got += colorStr.Str
+	}
+	blocks := []rune(glyphs().vBlocks)
+	if want := string([]rune{blocks[3], blocks[7]}); got != want {
+		t.Errorf("Line = %q, want %q", got, want)
+	}
+}
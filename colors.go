@@ -4,52 +4,275 @@ import (
 	"github.com/nsf/termbox-go"
 )
 
-type ColorStruct struct {
+// TermColor is a foreground or background color of a Style. The zero value
+// is the terminal's default color; the other values are one of the 16 basic
+// ANSI colors, an index into the xterm 256 color palette, or a 24-bit RGB
+// color (see Color256 and ColorRGB).
+type TermColor uint32
+
+// the top byte of a TermColor says what kind of color it is, the rest holds
+// the palette index or the packed RGB value
+const (
+	colorKindMask  TermColor = 0xff << 24
+	colorKindBasic TermColor = 1 << 24
+	colorKind256   TermColor = 2 << 24
+	colorKindRGB   TermColor = 3 << 24
+)
+
+const ColorDefault TermColor = 0
+
+// the 16 basic ANSI colors, in palette order
+const (
+	ColorBlack TermColor = colorKindBasic + iota
+	ColorRed
+	ColorGreen
+	ColorYellow
+	ColorBlue
+	ColorMagenta
+	ColorCyan
+	ColorWhite
+	ColorDarkGray
+	ColorLightRed
+	ColorLightGreen
+	ColorLightYellow
+	ColorLightBlue
+	ColorLightMagenta
+	ColorLightCyan
+	ColorBrightWhite
+)
+
+// Color256 returns the color at the given index of the xterm 256 color
+// palette. Indices 0-15 are the basic colors, 16-231 a 6x6x6 color cube and
+// 232-255 a grayscale ramp.
+func Color256(index uint8) TermColor {
+	return colorKind256 | TermColor(index)
+}
+
+// ColorRGB returns a 24-bit "truecolor" color
+func ColorRGB(r, g, b uint8) TermColor {
+	return colorKindRGB | TermColor(r)<<16 | TermColor(g)<<8 | TermColor(b)
+}
+
+func (c TermColor) IsDefault() bool {
+	return c&colorKindMask == 0
+}
+
+// basicPalette holds the RGB values xterm uses for the 16 basic colors
+var basicPalette = [16][3]uint8{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// the channel values of the 6x6x6 color cube in the 256 color palette
+var cubeLevels = [6]uint8{0, 95, 135, 175, 215, 255}
+
+// RGB returns the red, green and blue components of the color. The default
+// color has no fixed value, so it is reported as black.
+func (c TermColor) RGB() (r, g, b uint8) {
+	switch c & colorKindMask {
+	case colorKindBasic:
+		rgb := basicPalette[c&0xf]
+		return rgb[0], rgb[1], rgb[2]
+	case colorKind256:
+		idx := int(c & 0xff)
+		switch {
+		case idx < 16:
+			rgb := basicPalette[idx]
+			return rgb[0], rgb[1], rgb[2]
+		case idx < 232:
+			idx -= 16
+			return cubeLevels[idx/36], cubeLevels[(idx/6)%6], cubeLevels[idx%6]
+		default:
+			gray := uint8(8 + (idx-232)*10)
+			return gray, gray, gray
+		}
+	case colorKindRGB:
+		return uint8(c >> 16), uint8(c >> 8), uint8(c)
+	}
+	return 0, 0, 0
+}
+
+// attribute converts the color to a termbox attribute for the given output
+// mode. Colors the mode can't show are downsampled to the nearest one it has.
+func (c TermColor) attribute(mode termbox.OutputMode) termbox.Attribute {
+	if c.IsDefault() {
+		return termbox.ColorDefault
+	}
+	switch mode {
+	case termbox.OutputRGB:
+		return termbox.RGBToAttribute(c.RGB())
+	case termbox.Output216:
+		// the color cube of the 256 color palette, numbered from 1
+		r, g, b := c.RGB()
+		return termbox.Attribute(36*nearestCubeLevel(r)+6*nearestCubeLevel(g)+nearestCubeLevel(b)) + 1
+	case termbox.OutputGrayscale:
+		// 26 shades of gray, numbered from 1
+		return termbox.Attribute(nearestGray(c.RGB())) + 1
+	}
+	switch c & colorKindMask {
+	case colorKindBasic:
+		idx := termbox.Attribute(c & 0xf)
+		if mode == termbox.Output256 {
+			return idx + 1
+		}
+		if idx < 8 {
+			return idx + 1
+		}
+		// bright colors are bold colors on 8 color terminals
+		return (idx - 7) | termbox.AttrBold
+	case colorKind256:
+		idx := c & 0xff
+		if mode == termbox.Output256 {
+			return termbox.Attribute(idx) + 1
+		}
+		if idx < 16 {
+			return (colorKindBasic | idx).attribute(mode)
+		}
+	case colorKindRGB:
+		if mode == termbox.Output256 {
+			return nearest256(c.RGB()).attribute(mode)
+		}
+	}
+	// downsample to the basic colors
+	return nearestBasic(c.RGB()).attribute(mode)
+}
+
+// Style describes how a piece of text is drawn: its colors and attributes.
+// Styles are values, so the chainable methods return modified copies, e.g.
+//
+//	NewStyle().Fg(ColorWhite).Bg(ColorRed).Bold()
+type Style struct {
+	fg    TermColor
+	bg    TermColor
+	attrs uint8
+}
+
+const (
+	styleBold uint8 = 1 << iota
+	styleUnderline
+	styleReverse
+	styleBlink
+)
+
+// NewStyle returns a style with default colors and no attributes
+func NewStyle() Style {
+	return Style{}
+}
+
+func (s Style) Fg(c TermColor) Style {
+	s.fg = c
+	return s
+}
+
+func (s Style) Bg(c TermColor) Style {
+	s.bg = c
+	return s
+}
+
+func (s Style) Bold() Style {
+	s.attrs |= styleBold
+	return s
+}
+
+func (s Style) Underline() Style {
+	s.attrs |= styleUnderline
+	return s
+}
+
+func (s Style) Reverse() Style {
+	s.attrs |= styleReverse
+	return s
+}
+
+func (s Style) Blink() Style {
+	s.attrs |= styleBlink
+	return s
+}
+
+func (s Style) Foreground() TermColor { return s.fg }
+func (s Style) Background() TermColor { return s.bg }
+func (s Style) IsBold() bool          { return s.attrs&styleBold != 0 }
+func (s Style) IsUnderline() bool     { return s.attrs&styleUnderline != 0 }
+func (s Style) IsReverse() bool       { return s.attrs&styleReverse != 0 }
+func (s Style) IsBlink() bool         { return s.attrs&styleBlink != 0 }
+
+// Str makes a ColorStr drawn in this style
+func (s Style) Str(str string) ColorStr {
+	return ColorStr{Str: str, Style: s}
+}
+
+// attributes converts the style into termbox foreground and background
+// attributes for the current output mode
+func (s Style) attributes() (fg, bg termbox.Attribute) {
+	mode := termbox.SetOutputMode(termbox.OutputCurrent)
+	fg = s.fg.attribute(mode)
+	bg = s.bg.attribute(mode)
+	if s.IsBold() {
+		fg |= termbox.AttrBold
+	}
+	if s.IsUnderline() {
+		fg |= termbox.AttrUnderline
+	}
+	if s.IsReverse() {
+		fg |= termbox.AttrReverse
+	}
+	if s.IsBlink() {
+		fg |= termbox.AttrBlink
+	}
+	return fg, bg
 }
 
 // TODO does this belong in gopanes?
-// a termbox-go compatible colored strring
+// a termbox-go compatible colored string
 type ColorStr struct {
-	str string
-	fg  termbox.Attribute //TODO this should be better
-	bg  termbox.Attribute //TODO this should be better
+	Str   string
+	Style Style
 }
 
 type ColorRune struct {
-	r  rune
-	fg termbox.Attribute //TODO this should be better
-	bg termbox.Attribute //TODO this should be better
+	Ch    rune
+	Style Style
+}
+
+type ColorStruct struct {
 }
 
 var Color ColorStruct
 
 func (c *ColorStruct) Default(str string) ColorStr {
-	return ColorStr{str: str, fg: termbox.ColorDefault, bg: termbox.ColorDefault}
+	return NewStyle().Str(str)
 }
 func (c *ColorStruct) Black(str string) ColorStr {
-	return ColorStr{str: str, fg: termbox.ColorBlack, bg: termbox.ColorDefault}
+	return NewStyle().Fg(ColorBlack).Str(str)
 }
 func (c *ColorStruct) Red(str string) ColorStr {
-	return ColorStr{str: str, fg: termbox.ColorRed, bg: termbox.ColorDefault}
+	return NewStyle().Fg(ColorRed).Str(str)
 }
 func (c *ColorStruct) Green(str string) ColorStr {
-	return ColorStr{str: str, fg: termbox.ColorGreen, bg: termbox.ColorDefault}
+	return NewStyle().Fg(ColorGreen).Str(str)
 }
 func (c *ColorStruct) Yellow(str string) ColorStr {
-	return ColorStr{str: str, fg: termbox.ColorYellow, bg: termbox.ColorDefault}
+	return NewStyle().Fg(ColorYellow).Str(str)
 }
 func (c *ColorStruct) Blue(str string) ColorStr {
-	return ColorStr{str: str, fg: termbox.ColorBlue, bg: termbox.ColorDefault}
+	return NewStyle().Fg(ColorBlue).Str(str)
 }
 func (c *ColorStruct) Magenta(str string) ColorStr {
-	return ColorStr{str: str, fg: termbox.ColorMagenta, bg: termbox.ColorDefault}
+	return NewStyle().Fg(ColorMagenta).Str(str)
 }
 func (c *ColorStruct) Cyan(str string) ColorStr {
-	return ColorStr{str: str, fg: termbox.ColorCyan, bg: termbox.ColorDefault}
+	return NewStyle().Fg(ColorCyan).Str(str)
 }
 func (c *ColorStruct) White(str string) ColorStr {
-	return ColorStr{str: str, fg: termbox.ColorWhite, bg: termbox.ColorDefault}
+	return NewStyle().Fg(ColorWhite).Str(str)
 }
 func (c *ColorStruct) DarkGray(str string) ColorStr {
-	return ColorStr{str: str, fg: termbox.ColorBlack | termbox.AttrBold, bg: termbox.ColorDefault}
+	return NewStyle().Fg(ColorDarkGray).Str(str)
+}
+
+// Styled makes a ColorStr from any style
+func (c *ColorStruct) Styled(str string, style Style) ColorStr {
+	return style.Str(str)
 }
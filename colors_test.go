@@ -0,0 +1,89 @@
+package gopanes
+
+import (
+	"github.com/nsf/termbox-go"
+	"testing"
+)
+
+func TestStyleChaining(t *testing.T) {
+	base := NewStyle().Fg(ColorWhite)
+	alert := base.Bg(ColorRed).Bold()
+	if base.Background() != ColorDefault || base.IsBold() {
+		t.Errorf("chaining modified the original style: %+v", base)
+	}
+	if alert.Foreground() != ColorWhite || alert.Background() != ColorRed || !alert.IsBold() {
+		t.Errorf("unexpected style %+v", alert)
+	}
+	if str := alert.Str("ALERT"); str.Str != "ALERT" || str.Style != alert {
+		t.Errorf("unexpected ColorStr %+v", str)
+	}
+}
+
+func TestColorRGB(t *testing.T) {
+	tests := []struct {
+		color   TermColor
+		r, g, b uint8
+	}{
+		{ColorRGB(1, 2, 3), 1, 2, 3},
+		{ColorRed, 205, 0, 0},
+		{Color256(9), 255, 0, 0},
+		{Color256(16), 0, 0, 0},
+		{Color256(196), 255, 0, 0},
+		{Color256(232), 8, 8, 8},
+		{Color256(255), 238, 238, 238},
+	}
+	for _, test := range tests {
+		r, g, b := test.color.RGB()
+		if r != test.r || g != test.g || b != test.b {
+			t.Errorf("%#x: got %d,%d,%d want %d,%d,%d", test.color, r, g, b, test.r, test.g, test.b)
+		}
+	}
+}
+
+func TestColorAttribute(t *testing.T) {
+	if got := ColorDefault.attribute(termbox.Output256); got != termbox.ColorDefault {
+		t.Errorf("default color: got %v", got)
+	}
+	if got := ColorRed.attribute(termbox.OutputNormal); got != termbox.ColorRed {
+		t.Errorf("red in normal mode: got %v", got)
+	}
+	if got := ColorDarkGray.attribute(termbox.OutputNormal); got != termbox.ColorBlack|termbox.AttrBold {
+		t.Errorf("dark gray in normal mode: got %v", got)
+	}
+	if got := Color256(200).attribute(termbox.Output256); got != 201 {
+		t.Errorf("256 color in 256 mode: got %v", got)
+	}
+}
+
+func TestColorAttributeLimitedPalettes(t *testing.T) {
+	tests := []struct {
+		color TermColor
+		mode  termbox.OutputMode
+		want  termbox.Attribute
+	}{
+		// the color cube, numbered from 1
+		{ColorRGB(0, 0, 0), termbox.Output216, 1},
+		{ColorRed, termbox.Output216, 36*4 + 1},
+		{Color256(196), termbox.Output216, 36*5 + 1},
+		{ColorRGB(255, 255, 255), termbox.Output216, 216},
+		{Color256(244), termbox.Output216, 36*2 + 6*2 + 2 + 1},
+		// black, the grayscale ramp and white, numbered from 1
+		{ColorBlack, termbox.OutputGrayscale, 1},
+		{ColorRGB(255, 255, 255), termbox.OutputGrayscale, 26},
+		{ColorRGB(128, 128, 128), termbox.OutputGrayscale, 14},
+		{ColorRed, termbox.OutputGrayscale, 8},
+		{ColorDefault, termbox.OutputGrayscale, termbox.ColorDefault},
+	}
+	for _, test := range tests {
+		if got := test.color.attribute(test.mode); got != test.want {
+			t.Errorf("%#x in mode %d: got %d, want %d", test.color, test.mode, got, test.want)
+		}
+	}
+
+	defer termbox.SetOutputMode(termbox.SetOutputMode(termbox.OutputCurrent))
+	termbox.SetOutputMode(termbox.Output216)
+	fg, bg := NewStyle().Fg(ColorRed).Bg(ColorBlack).Bold().attributes()
+	if fg != (36*4+1)|termbox.AttrBold || bg != 1 {
+		t.Errorf("style attributes in 216 color mode = %d, %d", fg, bg)
+	}
+}
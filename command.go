@@ -0,0 +1,172 @@
+package gopanes
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CommandRun is a command whose output is being shown in a pane, see
+// GoPane.RunCommand
+type CommandRun struct {
+	cmd      *exec.Cmd
+	stop     chan struct{}
+	stopOnce sync.Once
+	finished chan struct{}
+	err      error // from cmd.Wait, set before finished is closed
+}
+
+// RunCommand starts cmd and adds what it writes to stdout and stderr to the
+// pane line by line, stderr in red, followed by a line with its exit status.
+// The command, along with any processes it started, is killed if ctx is
+// cancelled, the pane is closed (including by GoPaneUi.Close) or the run is
+// stopped. cmd's Stdout and Stderr must not
+// be set.
+func (gp *GoPane) RunCommand(ctx context.Context, cmd *exec.Cmd) (*CommandRun, error) {
+	return startCommand(ctx, gp.Done(), cmd, func(line []ColorStr) {
+		gp.AddLine(line)
+		gp.Refresh()
+	})
+}
+
+// how long output is still read after a command exits, for children it left
+// running with its stdout or stderr
+const commandOutputGrace = 500 * time.Millisecond
+
+// startCommand starts cmd, passing each line of its output and then the exit
+// status line to output. The command and the processes it started are killed
+// when ctx or done end.
+func startCommand(ctx context.Context, done <-chan struct{}, cmd *exec.Cmd, output func(line []ColorStr)) (*CommandRun, error) {
+	if cmd.Stdout != nil || cmd.Stderr != nil {
+		return nil, errors.New("command's Stdout or Stderr is already set")
+	}
+	stdout, stdoutWriter, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, stderrWriter, err := os.Pipe()
+	if err != nil {
+		stdout.Close()
+		stdoutWriter.Close()
+		return nil, err
+	}
+	cmd.Stdout, cmd.Stderr = stdoutWriter, stderrWriter
+	startProcessGroup(cmd)
+	err = cmd.Start()
+	// only the command needs the write ends
+	stdoutWriter.Close()
+	stderrWriter.Close()
+	if err != nil {
+		stdout.Close()
+		stderr.Close()
+		return nil, err
+	}
+	run := &CommandRun{
+		cmd:      cmd,
+		stop:     make(chan struct{}),
+		finished: make(chan struct{}),
+	}
+	// only one line is output at a time so they don't interleave
+	var outputLock sync.Mutex
+	var readers sync.WaitGroup
+	readers.Add(2)
+	stream := func(r io.Reader, style Style) {
+		defer readers.Done()
+		reader := bufio.NewReader(r)
+		for {
+			line, err := reader.ReadString('\n')
+			if line != "" {
+				line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+				outputLock.Lock()
+				output([]ColorStr{style.Str(line)})
+				outputLock.Unlock()
+			}
+			if err != nil {
+				return
+			}
+		}
+	}
+	go stream(stdout, NewStyle())
+	go stream(stderr, NewStyle().Fg(ColorRed))
+	exited := make(chan struct{})
+	killed := make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		case <-run.stop:
+		case <-exited:
+			killed <- false
+			return
+		}
+		// killing fails if the command had already exited
+		killed <- killProcessGroup(cmd) == nil
+	}()
+	go func() {
+		run.err = cmd.Wait()
+		close(exited)
+		readersDone := make(chan struct{})
+		go func() {
+			readers.Wait()
+			close(readersDone)
+		}()
+		// children left running can hold the pipes open forever, so reading
+		// stops a moment after the command exits
+		select {
+		case <-readersDone:
+		case <-time.After(commandOutputGrace):
+		}
+		stdout.Close()
+		stderr.Close()
+		<-readersDone
+		output(exitStatusLine(cmd, run.err, <-killed))
+		close(run.finished)
+	}()
+	return run, nil
+}
+
+// exitStatusLine describes how a command ended
+func exitStatusLine(cmd *exec.Cmd, err error, killed bool) []ColorStr {
+	name := strings.Join(cmd.Args, " ")
+	switch {
+	case killed && (cmd.ProcessState == nil || !cmd.ProcessState.Exited()):
+		return []ColorStr{Color.DarkGray("--- " + name + " killed ---")}
+	case cmd.ProcessState == nil:
+		return []ColorStr{Color.Red(fmt.Sprintf("--- %s failed: %v ---", name, err))}
+	case cmd.ProcessState.Success():
+		return []ColorStr{Color.DarkGray("--- " + name + " exited with status 0 ---")}
+	case cmd.ProcessState.ExitCode() < 0:
+		return []ColorStr{Color.Red(fmt.Sprintf("--- %s %v ---", name, cmd.ProcessState))}
+	}
+	return []ColorStr{Color.Red(fmt.Sprintf("--- %s exited with status %d ---", name, cmd.ProcessState.ExitCode()))}
+}
+
+// Wait waits for the command to finish and its output to be shown, returning
+// the error from exec.Cmd.Wait
+func (run *CommandRun) Wait() error {
+	<-run.finished
+	return run.err
+}
+
+// Done returns a channel that's closed when the command has finished
+func (run *CommandRun) Done() <-chan struct{} {
+	return run.finished
+}
+
+// Stop kills the command and waits for it to finish
+func (run *CommandRun) Stop() {
+	run.stopOnce.Do(func() { close(run.stop) })
+	<-run.finished
+}
+
+// Cmd returns the command being run
+func (run *CommandRun) Cmd() *exec.Cmd {
+	return run.cmd
+}
@@ -0,0 +1,113 @@
+package gopanes
+
+import (
+	"context"
+	"os/exec"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// runLines runs a command, collecting its output lines
+func runLines(t *testing.T, ctx context.Context, done <-chan struct{}, cmd *exec.Cmd) (*CommandRun, func() [][]ColorStr) {
+	lines := make(chan []ColorStr, 100)
+	run, err := startCommand(ctx, done, cmd, func(line []ColorStr) { lines <- line })
+	if err != nil {
+		t.Fatal(err)
+	}
+	return run, func() [][]ColorStr {
+		run.Wait()
+		close(lines)
+		var collected [][]ColorStr
+		for line := range lines {
+			collected = append(collected, line)
+		}
+		return collected
+	}
+}
+
+func TestRunCommandOutput(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "echo out; echo err >&2; printf partial; exit 3")
+	run, collect := runLines(t, context.Background(), nil, cmd)
+	lines := collect()
+	if _, ok := run.Wait().(*exec.ExitError); !ok {
+		t.Errorf("Wait() = %v, want an exit error", run.Wait())
+	}
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines, want 4", len(lines))
+	}
+	// stdout and stderr are read concurrently, but the footer is always last
+	var text []string
+	styles := map[string]Style{}
+	for _, line := range lines[:3] {
+		text = append(text, line[0].Str)
+		styles[line[0].Str] = line[0].Style
+	}
+	sort.Strings(text)
+	if want := []string{"err", "out", "partial"}; !reflect.DeepEqual(text, want) {
+		t.Errorf("output = %q, want %q", text, want)
+	}
+	if styles["err"] != NewStyle().Fg(ColorRed) || styles["out"] != NewStyle() {
+		t.Errorf("stderr style %v, stdout style %v", styles["err"], styles["out"])
+	}
+	if got, want := lineText(lines[3]), "--- sh -c echo out; echo err >&2; printf partial; exit 3 exited with status 3 ---"; got != want {
+		t.Errorf("footer = %q, want %q", got, want)
+	}
+}
+
+func TestRunCommandCancel(t *testing.T) {
+	done := make(chan struct{})
+	cmd := exec.Command("sleep", "10")
+	_, collect := runLines(t, context.Background(), done, cmd)
+	start := time.Now()
+	close(done)
+	lines := collect()
+	if time.Since(start) > 5*time.Second {
+		t.Error("the command wasn't killed when the pane was closed")
+	}
+	want := [][]ColorStr{{Color.DarkGray("--- sleep 10 killed ---")}}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("lines = %v, want %v", lines, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	run, _ := runLines(t, ctx, nil, exec.Command("sleep", "10"))
+	cancel()
+	if run.Wait() == nil {
+		t.Error("Wait() = nil after cancelling")
+	}
+}
+
+func TestRunCommandKillsChildren(t *testing.T) {
+	done := make(chan struct{})
+	// cat holds the pipes open as long as sleep runs
+	cmd := exec.Command("sh", "-c", "sleep 30 | cat")
+	run, collect := runLines(t, context.Background(), done, cmd)
+	time.Sleep(50 * time.Millisecond)
+	close(done)
+	select {
+	case <-run.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("the run didn't finish after the pane was closed")
+	}
+	if lines := collect(); len(lines) != 1 || lineText(lines[0]) != "--- sh -c sleep 30 | cat killed ---" {
+		t.Errorf("lines = %v", lines)
+	}
+}
+
+func TestRunCommandLeavesBackgroundChildren(t *testing.T) {
+	// the background sleep keeps stdout open after sh exits
+	cmd := exec.Command("sh", "-c", "echo started; sleep 30 &")
+	run, collect := runLines(t, context.Background(), nil, cmd)
+	defer killProcessGroup(cmd)
+	select {
+	case <-run.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("the run didn't finish after the command exited")
+	}
+	lines := collect()
+	if len(lines) != 2 || lineText(lines[0]) != "started" {
+		t.Errorf("lines = %v", lines)
+	}
+}
@@ -0,0 +1,302 @@
+package gopanes
+
+import (
+	"fmt"
+	"github.com/nsf/termbox-go"
+	"sync"
+)
+
+// ContentLog is the widget leaf panes start out with: a log of lines that
+// wraps them to fit and scrolls when there are too many
+type ContentLog struct {
+	content      []*contentLine
+	contentLock  sync.Mutex
+	maxLines     int // scrollback limit, 0 for none
+	scrollOffset int // rows scrolled back from the bottom of the content
+	wrapMode     WrapMode
+	hScroll      int // columns scrolled right when not wrapping
+	width        int // the size the log was last drawn at, less the search prompt
+	height       int
+	isFocused    bool
+	search       *logSearch // nil when not searching
+	copyMode     *copyMode  // nil when not in copy mode
+
+	showContinuation bool
+
+	// the styles search matches are highlighted with
+	MatchStyle        Style
+	CurrentMatchStyle Style
+	// the style of the cursor and the selection in copy mode
+	SelectionStyle Style
+}
+
+func NewContentLog() *ContentLog {
+	return &ContentLog{
+		MatchStyle:        NewStyle().Fg(ColorBlack).Bg(ColorYellow),
+		CurrentMatchStyle: NewStyle().Fg(ColorBlack).Bg(ColorLightYellow).Bold(),
+		SelectionStyle:    NewStyle().Reverse(),
+	}
+}
+
+// AddLine appends a line to the log. The returned handle can be used to
+// change the line later.
+func (cl *ContentLog) AddLine(colorStrs []ColorStr) LineHandle {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	line := &contentLine{colorStrs: colorStrs, log: cl}
+	cl.content = append(cl.content, line)
+	cl.trimScrollback()
+	return LineHandle{line: line}
+}
+
+// deletes all content
+func (cl *ContentLog) Clear() {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	for _, line := range cl.content {
+		line.removed = true
+	}
+	cl.content = nil
+}
+
+func (cl *ContentLog) Focus() { cl.isFocused = true }
+func (cl *ContentLog) Blur()  { cl.isFocused = false }
+
+// PreferredSize is the size of the widest line and the number of lines
+func (cl *ContentLog) PreferredSize() (width, height int) {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	for _, line := range cl.content {
+		for _, row := range wrapLine(line.colorStrs, 0, WrapNone, 0) {
+			if w := rowWidth(row); w > width {
+				width = w
+			}
+			height++
+		}
+	}
+	return width, height
+}
+
+func (cl *ContentLog) HandleEvent(ev termbox.Event) {
+	cl.contentLock.Lock()
+	copying := cl.copyMode != nil
+	height := cl.height
+	cl.contentLock.Unlock()
+	if copying {
+		var copied func()
+		if ev.Type == termbox.EventMouse {
+			copied = cl.handleCopyMouse(ev)
+		} else {
+			copied = cl.handleCopyKey(ev)
+		}
+		// onCopy may use the log, so it's called after unlocking
+		if copied != nil {
+			copied()
+		}
+		return
+	}
+	if ev.Type == termbox.EventKey && cl.handleSearchKey(ev) {
+		return
+	}
+	switch ev.Key {
+	case termbox.KeyArrowUp:
+		cl.ScrollUp(1)
+	case termbox.KeyArrowDown:
+		cl.ScrollDown(1)
+	case termbox.MouseWheelUp:
+		cl.ScrollUp(3)
+	case termbox.MouseWheelDown:
+		cl.ScrollDown(3)
+	case termbox.KeyPgup:
+		cl.ScrollUp(height)
+	case termbox.KeyPgdn:
+		cl.ScrollDown(height)
+	case termbox.KeyEnd:
+		cl.ScrollToBottom()
+	case termbox.KeyArrowLeft:
+		cl.ScrollLeft(1)
+	case termbox.KeyArrowRight:
+		cl.ScrollRight(1)
+	case termbox.KeyHome:
+		cl.contentLock.Lock()
+		cl.hScroll = 0
+		cl.contentLock.Unlock()
+	}
+}
+
+// ScrollUp scrolls the content back by the given number of rows
+func (cl *ContentLog) ScrollUp(rows int) {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	maxOffset := len(cl.wrapRows(cl.width)) - cl.height
+	cl.scrollOffset += rows
+	if cl.scrollOffset > maxOffset {
+		cl.scrollOffset = maxOffset
+	}
+	if cl.scrollOffset < 0 {
+		cl.scrollOffset = 0
+	}
+}
+
+// ScrollDown scrolls the content forward by the given number of rows
+func (cl *ContentLog) ScrollDown(rows int) {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	cl.scrollOffset -= rows
+	if cl.scrollOffset < 0 {
+		cl.scrollOffset = 0
+	}
+}
+
+// ScrollToBottom makes the log follow new content again
+func (cl *ContentLog) ScrollToBottom() {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	cl.scrollOffset = 0
+}
+
+// ScrollPosition describes which rows of the content are visible, or returns
+// an empty string if it all fits
+func (cl *ContentLog) ScrollPosition() string {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	totalRows := len(cl.wrapRows(cl.width))
+	if totalRows <= cl.height {
+		return ""
+	}
+	lastRow := cl.visibleRows(totalRows, cl.height) + cl.height
+	return fmt.Sprintf("[%d/%d]", lastRow, totalRows)
+}
+
+func (cl *ContentLog) wrapParams(width int) wrapParams {
+	var marker rune
+	if cl.showContinuation && cl.wrapMode != WrapNone {
+		marker = glyphs().continuation
+	}
+	return wrapParams{width: width, mode: cl.wrapMode, marker: marker}
+}
+
+// wrapContent wraps the content into rows of at most the given width
+func (cl *ContentLog) wrapContent(width int) [][]ColorRune {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	return cl.wrapRows(width)
+}
+
+// wrapRows is wrapContent for callers that already hold contentLock
+func (cl *ContentLog) wrapRows(width int) [][]ColorRune {
+	params := cl.wrapParams(width)
+	if cl.search != nil {
+		cl.search.find(cl.content)
+	}
+	var buf [][]ColorRune
+	for _, line := range cl.content {
+		if cl.search != nil {
+			// lines with matches are wrapped with the matches highlighted
+			if highlighted := cl.search.highlight(line, cl.MatchStyle, cl.CurrentMatchStyle); highlighted != nil {
+				buf = append(buf, wrapLine(highlighted, params.width, params.mode, params.marker)...)
+				continue
+			}
+		}
+		// lines are only wrapped again if they or the parameters changed
+		buf = append(buf, line.wrap(params)...)
+	}
+	return buf
+}
+
+// visibleRows returns the index of the first visible row of the wrapped
+// content, clamping the scroll offset to the content. The caller must hold
+// contentLock.
+func (cl *ContentLog) visibleRows(totalRows, height int) int {
+	if cl.scrollOffset > totalRows-height {
+		cl.scrollOffset = totalRows - height
+	}
+	if cl.scrollOffset < 0 {
+		cl.scrollOffset = 0
+	}
+	startRow := totalRows - height - cl.scrollOffset
+	if startRow < 0 {
+		startRow = 0
+	}
+	return startRow
+}
+
+func (cl *ContentLog) Draw(c *Canvas) {
+	full := c
+	// searches, copy mode and scrolling are changed from other goroutines
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	searching, copying := cl.search != nil, cl.copyMode != nil
+	if searching || copying {
+		// the search prompt or copy mode indicator takes the bottom row
+		c = c.Sub(0, 0, c.Width(), c.Height()-1)
+	}
+	width, height := c.Width(), c.Height()
+	cl.width, cl.height = width, height
+	buf := cl.wrapRows(width)
+	// set the cells in the termbox buffer (or at least, all that can fit)
+	startRow := cl.visibleRows(len(buf), height)
+	endRow := startRow + height
+	if endRow > len(buf) {
+		endRow = len(buf)
+	}
+	for rownum, row := range buf[startRow:endRow] {
+		if copying {
+			row = cl.highlightSelection(row, startRow+rownum)
+		}
+		cl.drawRow(c, rownum, row)
+	}
+	// set all empty rows as spaces
+	c.Fill(0, endRow-startRow, width, height, ' ', NewStyle())
+	if copying {
+		cl.drawCopyPrompt(full, len(buf))
+		return
+	}
+	if searching {
+		cl.drawSearchPrompt(full)
+	}
+}
+
+// drawRow draws one wrapped row of content, scrolled sideways and with
+// arrows marking cut off content if the log doesn't wrap. The caller must
+// hold contentLock.
+func (cl *ContentLog) drawRow(c *Canvas, y int, row []ColorRune) {
+	scroll := 0
+	if cl.wrapMode == WrapNone {
+		scroll = cl.hScroll
+	}
+	col := 0
+	for _, colorRune := range row {
+		w := runeWidth(colorRune.Ch, col)
+		// only draw runes that fit entirely in the visible columns, blanking
+		// the visible part of those cut off by the edges
+		if col < scroll || col+w > scroll+c.Width() {
+			for i := 0; i < w; i++ {
+				c.SetCell(col-scroll+i, y, ' ', colorRune.Style)
+			}
+		} else if w > 0 {
+			ch := colorRune.Ch
+			if ch == '\t' {
+				ch = ' '
+			}
+			c.SetCell(col-scroll, y, ch, colorRune.Style)
+			// tabs are several spaces, wide runes cover the cell after them
+			for i := 1; i < w; i++ {
+				c.SetCell(col-scroll+i, y, ' ', colorRune.Style)
+			}
+		}
+		col += w
+	}
+	if col-scroll < c.Width() {
+		c.Fill(col-scroll, y, c.Width()-(col-scroll), 1, ' ', NewStyle())
+	}
+	if cl.wrapMode != WrapNone {
+		return
+	}
+	if col-scroll > c.Width() {
+		c.SetCell(c.Width()-1, y, glyphs().rightArrow, NewStyle())
+	}
+	if scroll > 0 && col > 0 {
+		c.SetCell(0, y, glyphs().leftArrow, NewStyle())
+	}
+}
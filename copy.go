@@ -0,0 +1,360 @@
+package gopanes
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/nsf/termbox-go"
+	"os"
+	"strings"
+)
+
+// copyMode is the state of a content log's copy mode. Positions are a row of
+// the wrapped content and a rune in it.
+type copyMode struct {
+	row       int
+	col       int
+	anchorRow int // where the selection started
+	anchorCol int
+	selecting bool
+	onCopy    func(text string)
+}
+
+// selection returns the ends of the selection in order
+func (cm *copyMode) selection() (startRow, startCol, endRow, endCol int) {
+	startRow, startCol, endRow, endCol = cm.anchorRow, cm.anchorCol, cm.row, cm.col
+	if endRow < startRow || (endRow == startRow && endCol < startCol) {
+		startRow, startCol, endRow, endCol = endRow, endCol, startRow, startCol
+	}
+	return
+}
+
+// isSelected tells whether a position is in the selection, or under the
+// cursor when nothing is selected
+func (cm *copyMode) isSelected(row, col int) bool {
+	if !cm.selecting {
+		return row == cm.row && col == cm.col
+	}
+	startRow, startCol, endRow, endCol := cm.selection()
+	if row < startRow || row > endRow {
+		return false
+	}
+	if row == startRow && col < startCol {
+		return false
+	}
+	if row == endRow && col > endCol {
+		return false
+	}
+	return true
+}
+
+// StartCopyMode lets the user move a cursor over the log's content with the
+// keys and select text to copy. The arrow keys and h, j, k and l move the
+// cursor, PgUp/PgDn scroll, g and G go to the top and bottom, Home/0 and
+// End/$ to the start and end of the row, Space or v starts a selection and
+// Enter or y copies it, or the row under the cursor if nothing is selected.
+// Dragging the mouse selects text too, copying it on release. Esc or q leaves
+// copy mode. onCopy is called with the copied text.
+func (cl *ContentLog) StartCopyMode(onCopy func(text string)) {
+	cl.EndSearch()
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	buf := cl.wrapRows(cl.width)
+	if len(buf) == 0 {
+		return
+	}
+	// start at the bottom of the view
+	row := cl.visibleRows(len(buf), cl.height) + cl.height - 1
+	if row >= len(buf) {
+		row = len(buf) - 1
+	}
+	cl.copyMode = &copyMode{row: row, onCopy: onCopy}
+}
+
+// EndCopyMode leaves copy mode without copying anything
+func (cl *ContentLog) EndCopyMode() {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	cl.copyMode = nil
+}
+
+// lineStarts tells for each wrapped row whether it starts a line. The caller
+// must hold contentLock.
+func (cl *ContentLog) lineStarts(width int) []bool {
+	params := cl.wrapParams(width)
+	var starts []bool
+	for _, line := range cl.content {
+		for idx := range line.wrap(params) {
+			starts = append(starts, idx == 0)
+		}
+	}
+	return starts
+}
+
+// selectedText returns the text in the selection, with newlines between lines
+// but not between the rows a line was wrapped into. The caller must hold
+// contentLock.
+func (cl *ContentLog) selectedText(buf [][]ColorRune) string {
+	cm := cl.copyMode
+	startRow, startCol, endRow, endCol := cm.selection()
+	if !cm.selecting {
+		// copy the row under the cursor
+		startRow, startCol, endRow, endCol = cm.row, 0, cm.row, len(buf[cm.row])
+	}
+	starts := cl.lineStarts(cl.width)
+	marker := cl.wrapParams(cl.width).marker
+	var text strings.Builder
+	for row := startRow; row <= endRow && row < len(buf); row++ {
+		from, to := 0, len(buf[row])
+		if row == startRow {
+			from = startCol
+		}
+		if row == endRow && endCol+1 < to {
+			to = endCol + 1
+		}
+		if row > startRow && row < len(starts) && starts[row] {
+			text.WriteString("\n")
+		}
+		// leave out continuation markers
+		if row < len(starts) && !starts[row] && marker != 0 && from == 0 && to > 0 {
+			from = 1
+		}
+		for col := from; col < to; col++ {
+			text.WriteRune(buf[row][col].Ch)
+		}
+	}
+	return text.String()
+}
+
+// highlightSelection returns a copy of a row with the cursor or the selected
+// runes in the selection style. The caller must hold contentLock.
+func (cl *ContentLog) highlightSelection(row []ColorRune, rowIdx int) []ColorRune {
+	cm := cl.copyMode
+	if len(row) == 0 && rowIdx == cm.row {
+		// show the cursor on empty rows too
+		return []ColorRune{{Ch: ' ', Style: cl.SelectionStyle}}
+	}
+	styled := make([]ColorRune, len(row))
+	for col, colorRune := range row {
+		if cm.isSelected(rowIdx, col) {
+			colorRune.Style = inheritStyle(cl.SelectionStyle, colorRune.Style)
+		}
+		styled[col] = colorRune
+	}
+	return styled
+}
+
+// moveCopyCursor moves the cursor, keeping it on the content and scrolling to
+// keep it in view. The caller must hold contentLock.
+func (cl *ContentLog) moveCopyCursor(buf [][]ColorRune, row, col int) {
+	cm := cl.copyMode
+	if row >= len(buf) {
+		row = len(buf) - 1
+	}
+	if row < 0 {
+		row = 0
+	}
+	if row < len(buf) && col >= len(buf[row]) {
+		col = len(buf[row]) - 1
+	}
+	if col < 0 {
+		col = 0
+	}
+	cm.row, cm.col = row, col
+	startRow := cl.visibleRows(len(buf), cl.height)
+	if row < startRow {
+		cl.scrollOffset = len(buf) - cl.height - row
+	} else if row >= startRow+cl.height {
+		cl.scrollOffset = len(buf) - row - 1
+	}
+	if cl.wrapMode == WrapNone && row < len(buf) {
+		cl.scrollToColumn(rowWidth(buf[row][:col]))
+	}
+}
+
+// copySelection leaves copy mode and returns a function handing the selected
+// text to onCopy, to be called once contentLock is released. The caller must
+// hold contentLock.
+func (cl *ContentLog) copySelection(buf [][]ColorRune) func() {
+	cm := cl.copyMode
+	// the content may have been trimmed since the cursor was moved
+	cl.moveCopyCursor(buf, cm.row, cm.col)
+	text := cl.selectedText(buf)
+	cl.copyMode = nil
+	if cm.onCopy == nil {
+		return nil
+	}
+	return func() { cm.onCopy(text) }
+}
+
+// handleCopyKey handles keys in copy mode, returning the copy to make if the
+// key copied the selection
+func (cl *ContentLog) handleCopyKey(ev termbox.Event) (copied func()) {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	cm := cl.copyMode
+	if cm == nil {
+		return nil
+	}
+	buf := cl.wrapRows(cl.width)
+	// the content may have been cleared
+	if len(buf) == 0 {
+		cl.copyMode = nil
+		return nil
+	}
+	switch ev.Key {
+	case termbox.KeyArrowUp:
+		cl.moveCopyCursor(buf, cm.row-1, cm.col)
+	case termbox.KeyArrowDown:
+		cl.moveCopyCursor(buf, cm.row+1, cm.col)
+	case termbox.KeyArrowLeft:
+		cl.moveCopyCursor(buf, cm.row, cm.col-1)
+	case termbox.KeyArrowRight:
+		cl.moveCopyCursor(buf, cm.row, cm.col+1)
+	case termbox.KeyPgup:
+		cl.moveCopyCursor(buf, cm.row-cl.height, cm.col)
+	case termbox.KeyPgdn:
+		cl.moveCopyCursor(buf, cm.row+cl.height, cm.col)
+	case termbox.KeyHome:
+		cl.moveCopyCursor(buf, cm.row, 0)
+	case termbox.KeyEnd:
+		cl.moveCopyCursor(buf, cm.row, len(buf[cm.row]))
+	case termbox.KeySpace:
+		cm.selecting, cm.anchorRow, cm.anchorCol = true, cm.row, cm.col
+	case termbox.KeyEnter:
+		return cl.copySelection(buf)
+	case termbox.KeyEsc:
+		cl.copyMode = nil
+	default:
+		switch ev.Ch {
+		case 'k':
+			cl.moveCopyCursor(buf, cm.row-1, cm.col)
+		case 'j':
+			cl.moveCopyCursor(buf, cm.row+1, cm.col)
+		case 'h':
+			cl.moveCopyCursor(buf, cm.row, cm.col-1)
+		case 'l':
+			cl.moveCopyCursor(buf, cm.row, cm.col+1)
+		case 'g':
+			cl.moveCopyCursor(buf, 0, 0)
+		case 'G':
+			cl.moveCopyCursor(buf, len(buf)-1, 0)
+		case '0':
+			cl.moveCopyCursor(buf, cm.row, 0)
+		case '$':
+			cl.moveCopyCursor(buf, cm.row, len(buf[cm.row]))
+		case 'v':
+			cm.selecting, cm.anchorRow, cm.anchorCol = true, cm.row, cm.col
+		case 'y':
+			return cl.copySelection(buf)
+		case 'q':
+			cl.copyMode = nil
+		}
+	}
+	return nil
+}
+
+// handleCopyMouse handles the mouse in copy mode: pressing moves the cursor,
+// dragging selects and releasing after a drag copies the selection, which is
+// returned like handleCopyKey does
+func (cl *ContentLog) handleCopyMouse(ev termbox.Event) (copied func()) {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	cm := cl.copyMode
+	if cm == nil {
+		return nil
+	}
+	buf := cl.wrapRows(cl.width)
+	if len(buf) == 0 {
+		return nil
+	}
+	switch ev.Key {
+	case termbox.MouseWheelUp:
+		cl.moveCopyCursor(buf, cm.row-3, cm.col)
+	case termbox.MouseWheelDown:
+		cl.moveCopyCursor(buf, cm.row+3, cm.col)
+	case termbox.MouseLeft:
+		// clicks above the log, on the pane's title, are ignored
+		if ev.MouseY < 0 {
+			return nil
+		}
+		row := cl.visibleRows(len(buf), cl.height) + ev.MouseY
+		if row >= len(buf) {
+			row = len(buf) - 1
+		}
+		col := cl.columnAt(buf[row], ev.MouseX)
+		if ev.Mod&termbox.ModMotion == 0 {
+			cm.selecting, cm.anchorRow, cm.anchorCol = false, row, col
+		} else {
+			cm.selecting = true
+		}
+		cl.moveCopyCursor(buf, row, col)
+	case termbox.MouseRelease:
+		if cm.selecting {
+			return cl.copySelection(buf)
+		}
+	}
+	return nil
+}
+
+// columnAt returns the index of the rune drawn at x in a row. The caller must
+// hold contentLock.
+func (cl *ContentLog) columnAt(row []ColorRune, x int) int {
+	if cl.wrapMode == WrapNone {
+		x += cl.hScroll
+	}
+	col := 0
+	for idx, colorRune := range row {
+		col += runeWidth(colorRune.Ch, col)
+		if col > x {
+			return idx
+		}
+	}
+	return len(row) - 1
+}
+
+// drawCopyPrompt draws the copy mode indicator on the bottom row of the
+// canvas. The caller must hold contentLock.
+func (cl *ContentLog) drawCopyPrompt(c *Canvas, totalRows int) {
+	y := c.Height() - 1
+	c.Fill(0, y, c.Width(), 1, ' ', NewStyle())
+	label := "-- COPY --"
+	if cl.copyMode.selecting {
+		label = "-- COPY (selecting) --"
+	}
+	c.Print(0, y, label, NewStyle().Bold())
+	position := fmt.Sprintf("[%d/%d]", cl.copyMode.row+1, totalRows)
+	c.Print(c.Width()-len(position), y, position, NewStyle())
+}
+
+// osc52 returns the escape sequence that puts text on the terminal's
+// clipboard
+func osc52(text string) string {
+	return "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+}
+
+// CopyToClipboard puts text on the system clipboard using the OSC 52 escape
+// sequence. Not every terminal supports it, and some only do when configured
+// to.
+func CopyToClipboard(text string) error {
+	termboxMutex.Lock()
+	defer termboxMutex.Unlock()
+	_, err := os.Stdout.WriteString(osc52(text))
+	return err
+}
+
+// StartCopyMode puts the pane's content log in copy mode (see
+// ContentLog.StartCopyMode), copying the selection with the UI's Copy
+func (gp *GoPane) StartCopyMode() {
+	log := gp.ContentLog()
+	if log == nil {
+		return
+	}
+	log.StartCopyMode(func(text string) {
+		if gp.ui != nil {
+			gp.ui.Copy(text)
+		} else {
+			CopyToClipboard(text)
+		}
+	})
+	gp.Refresh()
+}
@@ -0,0 +1,73 @@
+package gopanes
+
+import (
+	"github.com/nsf/termbox-go"
+	"testing"
+)
+
+func TestCopySelection(t *testing.T) {
+	log := NewContentLog()
+	log.SetWrapMode(WrapChar)
+	log.ShowContinuation(true)
+	log.AddLine([]ColorStr{NewStyle().Str("first line")})
+	log.AddLine([]ColorStr{NewStyle().Str("a long second line")})
+	log.width, log.height = 8, 10
+	var copied string
+	log.StartCopyMode(func(text string) { copied = text })
+	buf := log.wrapContent(log.width)
+	// select from "line" on the first row to "second" on the wrapped rows
+	log.moveCopyCursor(buf, 0, 6)
+	log.HandleEvent(keyEvent('v'))
+	log.moveCopyCursor(buf, 3, 5)
+	log.HandleEvent(keyEvent('y'))
+	if want := "line\na long second"; copied != want {
+		t.Errorf("copied %q, want %q", copied, want)
+	}
+	if log.copyMode != nil {
+		t.Error("copying didn't leave copy mode")
+	}
+	if got, want := osc52("hi"), "\x1b]52;c;aGk=\a"; got != want {
+		t.Errorf("osc52 = %q, want %q", got, want)
+	}
+}
+
+func TestCopyMouseOutsideLog(t *testing.T) {
+	log := NewContentLog()
+	log.AddLine([]ColorStr{NewStyle().Str("only line")})
+	log.width, log.height = 20, 5
+	log.StartCopyMode(nil)
+	// a click on the title row of the pane
+	log.handleCopyMouse(termbox.Event{Type: termbox.EventMouse, Key: termbox.MouseLeft, MouseY: -1})
+	if log.copyMode.row != 0 || log.copyMode.col != 0 {
+		t.Errorf("cursor moved to %d,%d", log.copyMode.row, log.copyMode.col)
+	}
+}
+
+func TestCopyModeWhileDrawing(t *testing.T) {
+	log := NewContentLog()
+	for i := 0; i < 10; i++ {
+		log.AddLine([]ColorStr{NewStyle().Str("line")})
+	}
+	ms := newMemoryScreen(10, 3)
+	log.Draw(newCanvasOn(ms, 0, 0, 10, 3))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			log.StartCopyMode(nil)
+			log.HandleEvent(keyEvent('k'))
+			log.EndCopyMode()
+			log.ScrollUp(1)
+			log.ScrollRight(1)
+			log.ScrollDown(1)
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		log.Draw(newCanvasOn(ms, 0, 0, 10, 3))
+	}
+	<-done
+}
+
+func keyEvent(ch rune) termbox.Event {
+	return termbox.Event{Type: termbox.EventKey, Ch: ch}
+}
@@ -0,0 +1,234 @@
+package gopanes
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// ExportFormat is a format pane content can be exported in
+type ExportFormat int
+
+const (
+	ExportText ExportFormat = iota // plain text without colors
+	ExportANSI                     // text with ANSI escape sequences for the colors
+	ExportHTML                     // a standalone HTML page
+)
+
+// colors exported HTML uses for the terminal's default colors
+const (
+	htmlDefaultFg = "#e5e5e5"
+	htmlDefaultBg = "#000000"
+)
+
+// sgr returns the SGR parameters selecting the color, which are never
+// downsampled since the output may be viewed somewhere else
+func (c TermColor) sgr(background bool) string {
+	base := 30
+	if background {
+		base = 40
+	}
+	switch c & colorKindMask {
+	case colorKindBasic:
+		idx := int(c & 0xf)
+		if idx < 8 {
+			return fmt.Sprint(base + idx)
+		}
+		return fmt.Sprint(base + 60 + idx - 8)
+	case colorKind256:
+		return fmt.Sprintf("%d;5;%d", base+8, c&0xff)
+	case colorKindRGB:
+		r, g, b := c.RGB()
+		return fmt.Sprintf("%d;2;%d;%d;%d", base+8, r, g, b)
+	}
+	return fmt.Sprint(base + 9)
+}
+
+// ansi returns the escape sequence switching to the style
+func (s Style) ansi() string {
+	params := []string{"0"}
+	if s.IsBold() {
+		params = append(params, "1")
+	}
+	if s.IsUnderline() {
+		params = append(params, "4")
+	}
+	if s.IsBlink() {
+		params = append(params, "5")
+	}
+	if s.IsReverse() {
+		params = append(params, "7")
+	}
+	if !s.fg.IsDefault() {
+		params = append(params, s.fg.sgr(false))
+	}
+	if !s.bg.IsDefault() {
+		params = append(params, s.bg.sgr(true))
+	}
+	return "\x1b[" + strings.Join(params, ";") + "m"
+}
+
+func cssColor(c TermColor) string {
+	r, g, b := c.RGB()
+	return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+}
+
+// cssColors returns the style's foreground and background as CSS colors,
+// empty for the default colors, with reverse video applied
+func (s Style) cssColors() (fg, bg string) {
+	if !s.fg.IsDefault() {
+		fg = cssColor(s.fg)
+	}
+	if !s.bg.IsDefault() {
+		bg = cssColor(s.bg)
+	}
+	if s.IsReverse() {
+		if fg == "" {
+			fg = htmlDefaultFg
+		}
+		if bg == "" {
+			bg = htmlDefaultBg
+		}
+		fg, bg = bg, fg
+	}
+	return fg, bg
+}
+
+// css returns the inline CSS for the style, empty for the default style
+func (s Style) css() string {
+	fg, bg := s.cssColors()
+	var css []string
+	if fg != "" {
+		css = append(css, "color:"+fg)
+	}
+	if bg != "" {
+		css = append(css, "background-color:"+bg)
+	}
+	if s.IsBold() {
+		css = append(css, "font-weight:bold")
+	}
+	var decorations []string
+	if s.IsUnderline() {
+		decorations = append(decorations, "underline")
+	}
+	if s.IsBlink() {
+		decorations = append(decorations, "blink")
+	}
+	if len(decorations) > 0 {
+		css = append(css, "text-decoration:"+strings.Join(decorations, " "))
+	}
+	return strings.Join(css, ";")
+}
+
+// writeHTMLHeader starts a standalone HTML page with a <pre> for the content
+func writeHTMLHeader(w io.Writer) {
+	fmt.Fprintf(w, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n"+
+		"<style>body { background-color: %s; color: %s; } pre { font-family: monospace; }</style>\n"+
+		"</head>\n<body>\n<pre>", htmlDefaultBg, htmlDefaultFg)
+}
+
+const htmlFooter = "</pre>\n</body>\n</html>\n"
+
+// exportLines writes lines of content in the given format
+func exportLines(w io.Writer, lines [][]ColorStr, format ExportFormat) error {
+	out := bufio.NewWriter(w)
+	if format == ExportHTML {
+		writeHTMLHeader(out)
+	}
+	for _, line := range lines {
+		for _, colorStr := range line {
+			switch format {
+			case ExportText:
+				out.WriteString(colorStr.Str)
+			case ExportANSI:
+				out.WriteString(colorStr.Style.ansi() + colorStr.Str)
+			case ExportHTML:
+				if css := colorStr.Style.css(); css != "" {
+					fmt.Fprintf(out, "<span style=\"%s\">%s</span>", css, html.EscapeString(colorStr.Str))
+				} else {
+					out.WriteString(html.EscapeString(colorStr.Str))
+				}
+			}
+		}
+		if format == ExportANSI {
+			out.WriteString("\x1b[0m")
+		}
+		out.WriteString("\n")
+	}
+	if format == ExportHTML {
+		out.WriteString(htmlFooter)
+	}
+	return out.Flush()
+}
+
+// Export writes all of the log's lines, not just the visible ones, in the
+// given format
+func (cl *ContentLog) Export(w io.Writer, format ExportFormat) error {
+	cl.contentLock.Lock()
+	lines := make([][]ColorStr, len(cl.content))
+	for idx, line := range cl.content {
+		lines[idx] = line.colorStrs
+	}
+	cl.contentLock.Unlock()
+	return exportLines(w, lines, format)
+}
+
+// Export writes the pane's content in the given format. It returns an error if
+// the pane doesn't have a content log.
+func (gp *GoPane) Export(w io.Writer, format ExportFormat) error {
+	log := gp.ContentLog()
+	if log == nil {
+		return fmt.Errorf("pane has no content log to export")
+	}
+	return log.Export(w, format)
+}
+
+// ExportString returns the pane's content in the given format
+func (gp *GoPane) ExportString(format ExportFormat) (string, error) {
+	var buf strings.Builder
+	err := gp.Export(&buf, format)
+	return buf.String(), err
+}
+
+// SaveContent writes the pane's content to a file in the given format
+func (gp *GoPane) SaveContent(path string, format ExportFormat) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	return gp.exportTo(file, format)
+}
+
+// exportTo writes the pane's content to a file and closes it
+func (gp *GoPane) exportTo(file *os.File, format ExportFormat) error {
+	if err := gp.Export(file, format); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// saveSnapshot saves the pane's content as text to a new file in the current
+// directory named after the time, numbered if there's already a file with
+// that name, and returns its name
+func (gp *GoPane) saveSnapshot() (string, error) {
+	base := "gopanes-" + time.Now().Format("20060102-150405")
+	for n := 0; ; n++ {
+		name := base + ".txt"
+		if n > 0 {
+			name = fmt.Sprintf("%s-%d.txt", base, n)
+		}
+		file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return name, gp.exportTo(file, ExportText)
+	}
+}
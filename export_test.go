@@ -0,0 +1,83 @@
+package gopanes
+
+import (
+	"github.com/nsf/termbox-go"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExport(t *testing.T) {
+	log := NewContentLog()
+	log.AddLine([]ColorStr{NewStyle().Str("plain "), NewStyle().Fg(ColorRed).Bold().Str("<red>")})
+	log.AddLine([]ColorStr{NewStyle().Bg(Color256(202)).Str("x"), NewStyle().Fg(ColorRGB(1, 2, 3)).Str("y")})
+
+	tests := []struct {
+		format ExportFormat
+		want   string
+	}{
+		{ExportText, "plain <red>\nxy\n"},
+		{ExportANSI, "\x1b[0mplain \x1b[0;1;31m<red>\x1b[0m\n" +
+			"\x1b[0;48;5;202mx\x1b[0;38;2;1;2;3my\x1b[0m\n"},
+	}
+	for _, test := range tests {
+		var buf strings.Builder
+		if err := log.Export(&buf, test.format); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("Export(%d) = %q, want %q", test.format, got, test.want)
+		}
+	}
+
+	var buf strings.Builder
+	log.Export(&buf, ExportHTML)
+	want := `plain <span style="color:#cd0000;font-weight:bold">&lt;red&gt;</span>`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("HTML export %q doesn't contain %q", buf.String(), want)
+	}
+}
+
+func TestSaveSnapshot(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	ui, ms := newTestUi(t, 60, 3)
+	ui.SetStatusBar(&StatusBar{Left: []StatusSegment{MessageSegment()}, Interval: time.Hour})
+	ui.FocusPane(ui.Root)
+	ui.Root.SetTitle("log")
+	ui.Root.SetTitleStatus("running")
+	ui.Root.AddLine([]ColorStr{Color.Default("content")})
+	var names []string
+	for i := 0; i < 2; i++ {
+		ui.HandleCommand(termbox.Event{Type: termbox.EventKey, Ch: 'S'})
+		message := lineText(ui.message)
+		if !strings.HasPrefix(message, "saved ") {
+			t.Fatalf("message = %q", message)
+		}
+		names = append(names, strings.TrimPrefix(message, "saved "))
+		if got := strings.TrimRight(screenRows(ms)[2], " "); got != message {
+			t.Errorf("status bar = %q, want %q", got, message)
+		}
+		// the pane's own status is left alone
+		if ui.Root.titleStatus != "running" {
+			t.Errorf("title status = %q, want %q", ui.Root.titleStatus, "running")
+		}
+	}
+	// saves in the same second don't overwrite each other
+	if names[0] == names[1] {
+		t.Fatalf("both saves went to %s", names[0])
+	}
+	for _, name := range names {
+		if data, err := os.ReadFile(name); err != nil || string(data) != "content\n" {
+			t.Errorf("%s has %q, %v", name, data, err)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package gopanes
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type FollowOptions struct {
+	// how many of the file's last lines to show when it's first opened, like
+	// tail -n; new lines are shown either way
+	Lines int
+	// how often the file is checked for new lines, defaults to a quarter of a
+	// second
+	Interval time.Duration
+}
+
+// Follower streams the lines appended to a file into a pane, see
+// GoPane.Follow
+type Follower struct {
+	path     string
+	opts     FollowOptions
+	file     *os.File
+	info     os.FileInfo // of the open file, to notice it being replaced
+	offset   int64
+	partial  string // the start of a line that hasn't been ended yet
+	started  bool   // the file has been looked for before
+	stop     chan struct{}
+	stopOnce sync.Once
+	stopped  chan struct{}
+}
+
+// Follow shows the lines appended to a file in the pane, like tail -F. It
+// keeps going when the file is truncated, or replaced as when logs are
+// rotated, and waits for the file if it doesn't exist yet. It stops when the
+// pane is closed or the follower is stopped.
+func (gp *GoPane) Follow(path string, opts FollowOptions) *Follower {
+	if opts.Interval <= 0 {
+		opts.Interval = 250 * time.Millisecond
+	}
+	f := &Follower{
+		path:    path,
+		opts:    opts,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go f.run(gp)
+	return f
+}
+
+// Stop stops following the file and waits until no more lines will be added
+func (f *Follower) Stop() {
+	f.stopOnce.Do(func() { close(f.stop) })
+	<-f.stopped
+}
+
+func (f *Follower) run(gp *GoPane) {
+	defer close(f.stopped)
+	defer f.close()
+	ticker := time.NewTicker(f.opts.Interval)
+	defer ticker.Stop()
+	for {
+		if lines := f.poll(); len(lines) > 0 {
+			for _, line := range lines {
+				gp.AddLine(line)
+			}
+			gp.Refresh()
+		}
+		select {
+		case <-ticker.C:
+		case <-gp.Done():
+			return
+		case <-f.stop:
+			return
+		}
+	}
+}
+
+func (f *Follower) close() {
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+}
+
+// notice is a line the follower adds to say what happened to the file
+func (f *Follower) notice(what string) []ColorStr {
+	return []ColorStr{Color.DarkGray("--- " + f.path + " " + what + " ---")}
+}
+
+// poll returns the lines added to the file since the last poll
+func (f *Follower) poll() [][]ColorStr {
+	var lines [][]ColorStr
+	if f.file == nil {
+		opened := f.open()
+		switch {
+		case opened && f.started:
+			// the file appeared or was replaced, so all of it is new
+			lines = append(lines, f.notice("opened"))
+		case opened && f.opts.Lines > 0:
+			lines = append(lines, f.lastLines()...)
+		case opened:
+			// only new lines are wanted
+			f.offset = f.info.Size()
+		}
+		f.started = true
+		if !opened {
+			return nil
+		}
+	}
+	info, err := f.file.Stat()
+	if err != nil {
+		return lines
+	}
+	if info.Size() < f.offset {
+		lines = append(lines, f.notice("truncated"))
+		f.offset, f.partial = 0, ""
+	}
+	lines = append(lines, f.read()...)
+	// a different file at the path means it was rotated; the rest of the
+	// old one has been read, so switch to the new one
+	if current, err := os.Stat(f.path); err == nil && !os.SameFile(current, f.info) {
+		f.close()
+		if f.partial != "" {
+			lines = append(lines, []ColorStr{Color.Default(f.partial)})
+			f.partial = ""
+		}
+		f.offset = 0
+	}
+	return lines
+}
+
+// open opens the file, returning false if it can't be opened yet
+func (f *Follower) open() bool {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return false
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return false
+	}
+	f.file, f.info = file, info
+	return true
+}
+
+// read returns the complete lines from the offset to the end of the file
+func (f *Follower) read() [][]ColorStr {
+	if _, err := f.file.Seek(f.offset, io.SeekStart); err != nil {
+		return nil
+	}
+	data, err := ioutil.ReadAll(f.file)
+	f.offset += int64(len(data))
+	if err != nil && len(data) == 0 {
+		return nil
+	}
+	text := f.partial + string(data)
+	parts := strings.Split(text, "\n")
+	// the last part hasn't been ended with a newline yet
+	f.partial = parts[len(parts)-1]
+	var lines [][]ColorStr
+	for _, part := range parts[:len(parts)-1] {
+		lines = append(lines, []ColorStr{Color.Default(strings.TrimSuffix(part, "\r"))})
+	}
+	return lines
+}
+
+// lastLines reads the whole file, keeping only its last lines
+func (f *Follower) lastLines() [][]ColorStr {
+	lines := f.read()
+	if len(lines) > f.opts.Lines {
+		lines = lines[len(lines)-f.opts.Lines:]
+	}
+	return lines
+}
@@ -0,0 +1,78 @@
+package gopanes
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func followedText(lines [][]ColorStr) []string {
+	var text []string
+	for _, line := range lines {
+		text = append(text, lineText(line))
+	}
+	return text
+}
+
+func appendFile(t *testing.T, path, text string) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(text); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFollowerPoll(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "one\ntwo\nthree\n")
+	f := &Follower{path: path, opts: FollowOptions{Lines: 2}}
+	defer f.close()
+
+	steps := []struct {
+		name   string
+		change func()
+		want   []string
+	}{
+		{"last lines", func() {}, []string{"two", "three"}},
+		{"partial line", func() { appendFile(t, path, "fo") }, nil},
+		{"appended", func() { appendFile(t, path, "ur\r\nfive\n") }, []string{"four", "five"}},
+		{"truncated", func() {
+			if err := os.Truncate(path, 0); err != nil {
+				t.Fatal(err)
+			}
+			appendFile(t, path, "six\n")
+		}, []string{"--- " + path + " truncated ---", "six"}},
+		{"rotated", func() {
+			appendFile(t, path, "seven\n")
+			if err := os.Rename(path, path+".1"); err != nil {
+				t.Fatal(err)
+			}
+			appendFile(t, path, "eight\n")
+		}, []string{"seven"}},
+		{"reopened", func() {}, []string{"--- " + path + " opened ---", "eight"}},
+	}
+	for _, step := range steps {
+		step.change()
+		if got := followedText(f.poll()); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: poll() = %q, want %q", step.name, got, step.want)
+		}
+	}
+}
+
+func TestFollowerWaitsForFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f := &Follower{path: path}
+	defer f.close()
+	if got := f.poll(); got != nil {
+		t.Errorf("poll() before the file exists = %q", followedText(got))
+	}
+	appendFile(t, path, "first\n")
+	want := []string{"--- " + path + " opened ---", "first"}
+	if got := followedText(f.poll()); !reflect.DeepEqual(got, want) {
+		t.Errorf("poll() = %q, want %q", got, want)
+	}
+}
@@ -3,7 +3,6 @@ package gopanes
 import (
 	"fmt"
 	"github.com/nsf/termbox-go"
-	"strconv"
 	"sync"
 )
 
@@ -14,32 +13,108 @@ var termboxMutex = &sync.Mutex{}
 
 func TermboxSafeFlush() {
 	termboxMutex.Lock()
-	termbox.Flush()
+	drawScreen.Flush()
 	termboxMutex.Unlock()
 }
 
 type GoPaneUi struct {
-	Root *GoPane
+	Root          *GoPane
+	colorSupport  ColorSupport
+	theme         *Theme
+	statusBar     *StatusBar
+	statusStop    chan struct{} // stops the status bar ticker
+	prefixPending bool          // the prefix key was pressed, waiting for a command, changed under termboxMutex
+	pasteBuffers  []PasteBuffer // most recent first
+	nextBuffer    int           // numbers the names of copied buffers
+	overlay       *overlay      // a window shown over the panes, if any
+	message       []ColorStr    // the result of the last prefix command, see MessageSegment, changed under termboxMutex
 }
 
 func (gu *GoPaneUi) getWindowWidth() int {
-	x, _ := termbox.Size()
+	x, _ := drawScreen.Size()
 
 	return x
 }
 
 func (gu *GoPaneUi) getWindowHeight() int {
-	_, y := termbox.Size()
+	_, y := drawScreen.Size()
 
 	return y
 }
 
 func (gu *GoPaneUi) Refresh() {
-	gu.Root.Refresh()
+	termboxMutex.Lock()
+	defer termboxMutex.Unlock()
+	gu.render()
+	drawScreen.Flush()
+}
+
+// render draws the status bar and all the panes. The caller must hold
+// termboxMutex.
+func (gu *GoPaneUi) render() {
+	gu.drawStatusBar()
+	gu.Root.render()
+}
+
+// SetColorSupport overrides the detected color support of the terminal.
+// ColorSupportAuto goes back to detecting it from the environment.
+func (gu *GoPaneUi) SetColorSupport(cs ColorSupport) {
+	if cs == ColorSupportAuto {
+		cs = DetectColorSupport()
+	}
+	gu.colorSupport = cs
+	termbox.SetOutputMode(cs.outputMode())
+}
+
+func (gu *GoPaneUi) ColorSupport() ColorSupport {
+	return gu.colorSupport
+}
+
+// SetASCIIOnly switches divider and arrow glyphs to plain ASCII, which is the
+// default when the locale isn't UTF-8. The glyphs are shared by every UI in
+// the process.
+func (gu *GoPaneUi) SetASCIIOnly(asciiOnly bool) {
+	// panes aren't drawn with a mix of both
+	termboxMutex.Lock()
+	defer termboxMutex.Unlock()
+	if asciiOnly {
+		setGlyphs(&asciiGlyphs)
+	} else {
+		setGlyphs(&unicodeGlyphs)
+	}
+}
+
+// SetTheme changes how dividers and focus are drawn. A nil theme goes back to
+// the default one.
+func (gu *GoPaneUi) SetTheme(theme *Theme) {
+	gu.theme = theme
+	gu.Refresh()
+}
+
+// Theme returns the UI's theme, or a copy of the default one if it has none
+func (gu *GoPaneUi) Theme() *Theme {
+	if gu.theme == nil {
+		return DefaultTheme()
+	}
+	return gu.theme
+}
+
+// currentTheme is Theme for drawing, without copying the default theme each
+// time. The theme it returns mustn't be changed.
+func (gu *GoPaneUi) currentTheme() *Theme {
+	if gu.theme == nil {
+		return defaultTheme()
+	}
+	return gu.theme
 }
 
 // Close MUST be called on program exit to clean up after termbox
 func (gu *GoPaneUi) Close() {
+	gu.Root.Close()
+	if gu.statusStop != nil {
+		close(gu.statusStop)
+		gu.statusStop = nil
+	}
 	termbox.Close()
 }
 
@@ -54,8 +129,14 @@ type GoPane struct {
 	y             int
 	width         int
 	height        int
-	content       [][]ColorStr
-	editBox       *EditBox
+	widget        Widget    // what a leaf pane shows, nil for split panes
+	ui            *GoPaneUi // the UI the pane is in, or nil if it isn't in one
+	title         string    // shown on a header row if it isn't empty
+	titleStatus   string    // right aligned on the header row
+	done          chan struct{}
+	closeOnce     sync.Once
+	process       *Process // the supervised process shown in the pane, if any
+	watcher       *Watcher // the watcher updating the pane, if any
 }
 
 func NewGoPane(width int, height int, x int, y int) *GoPane {
@@ -64,10 +145,31 @@ func NewGoPane(width int, height int, x int, y int) *GoPane {
 		height: height,
 		x:      x,
 		y:      y,
+		widget: NewContentLog(),
+		done:   make(chan struct{}),
 		First:  nil,
 		Second: nil}
 }
 
+// Close stops whatever is feeding the pane and its children, such as files
+// being followed. The panes stay on the screen.
+func (gp *GoPane) Close() {
+	gp.closeOnce.Do(func() {
+		if gp.done != nil {
+			close(gp.done)
+		}
+	})
+	if gp.isSplit() {
+		gp.First.Close()
+		gp.Second.Close()
+	}
+}
+
+// Done returns a channel that's closed when the pane is closed
+func (gp *GoPane) Done() <-chan struct{} {
+	return gp.done
+}
+
 func (gu *GoPaneUi) GetFocusedPane() *GoPane {
 	return gu.Root.GetFocusedChild()
 }
@@ -128,30 +230,67 @@ func (gu *GoPaneUi) HandleCommand(ev termbox.Event) {
 			gu.ResizeLeft(target)
 		case 'H':
 			gu.ResizeRight(target)
+		case '/':
+			target.StartSearch()
+		case '[':
+			target.StartCopyMode()
+		case ']':
+			gu.Paste(target, "")
+		case '=':
+			gu.ChooseBuffer(target)
+		case 'S':
+			if name, err := target.saveSnapshot(); err != nil {
+				gu.showMessage(Color.Red("save failed: "+err.Error()))
+			} else {
+				gu.showMessage(Color.Default("saved "+name))
+			}
+		case 'r':
+			if p := target.Process(); p != nil {
+				p.Restart()
+			}
+		case 'x':
+			if p := target.Process(); p != nil {
+				p.Stop()
+			}
+		case 'p':
+			if w := target.Watcher(); w != nil {
+				w.TogglePause()
+			}
 		}
 	}
 }
 
 func (gu *GoPaneUi) Listen() {
 	termbox.SetInputMode(termbox.InputEsc | termbox.InputMouse)
-	var prefix bool
 	for {
 		switch ev := termbox.PollEvent(); ev.Type {
 		case termbox.EventMouse:
+			// the panes under an overlay don't get the mouse
+			if gu.overlay != nil {
+				gu.handleOverlayEvent(ev)
+				break
+			}
+			target := gu.GetTargetPane(ev.MouseX, ev.MouseY)
+			if target == nil {
+				break
+			}
 			// focus on clicked panes
 			if ev.Key == termbox.MouseRelease {
-				target := gu.GetTargetPane(ev.MouseX, ev.MouseY)
-				if target != nil {
-					gu.FocusPane(target)
-				}
+				gu.FocusPane(target)
 			}
+			// the pane under the mouse gets the event, focused or not
+			target.HandleEvent(ev)
+		case termbox.EventResize:
+			gu.Resize()
 		case termbox.EventKey:
 			// TODO if it's kill signal, just quit
-			if prefix {
+			if gu.prefixPending {
+				gu.setPrefixPending(false)
 				gu.HandleCommand(ev)
-				prefix = false
 			} else if ev.Key == termbox.KeyCtrlG { // TODO custom prefix
-				prefix = true
+				gu.setPrefixPending(true)
+			} else if gu.overlay != nil {
+				gu.handleOverlayEvent(ev)
 			} else {
 				// get target pane
 				target := gu.GetFocusedPane()
@@ -164,15 +303,30 @@ func (gu *GoPaneUi) Listen() {
 		case termbox.EventError:
 			panic(ev.Err)
 		}
+		// segments may show state the event changed
+		gu.RefreshStatusBar()
 	}
 
 }
 
+// Resize lays the panes out again to fit the window. The listener calls it when
+// the terminal is resized.
+func (gu *GoPaneUi) Resize() {
+	termboxMutex.Lock()
+	defer termboxMutex.Unlock()
+	drawScreen.Clear()
+	gu.Root.setBounds(gu.paneArea())
+	gu.render()
+	drawScreen.Flush()
+}
+
 // This is the ONLY function that should be used to focus a pane
 //  Using an individual pane's focus() will cause inconsistent state, since
 //  it could allow multiple panes to be focused
 func (gu *GoPaneUi) FocusPane(gp *GoPane) {
 	gu.Root.focusChild(gp)
+	// the focused pane's dividers are highlighted, so redraw everything
+	gu.Refresh()
 }
 
 func (gu *GoPaneUi) GetTargetPane(x, y int) *GoPane {
@@ -186,8 +340,11 @@ func NewGoPaneUi() *GoPaneUi {
 	var newUi GoPaneUi
 
 	termbox.Init()
+	newUi.SetColorSupport(ColorSupportAuto)
+	newUi.SetASCIIOnly(!localeIsUTF8())
 
 	newUi.Root = NewGoPane(newUi.getWindowWidth(), newUi.getWindowHeight(), 0, 0)
+	newUi.Root.ui = &newUi
 
 	go newUi.Listen()
 
@@ -219,39 +376,80 @@ func (gp *GoPane) IsInBounds(x, y int) bool {
 }
 
 func (gp *GoPane) HandleKey(key termbox.Key) {
-	switch key {
-	case termbox.KeyArrowUp:
-	case termbox.KeyArrowDown:
-	case termbox.KeyArrowLeft:
-	case termbox.KeyArrowRight:
-	}
+	gp.HandleEvent(termbox.Event{Type: termbox.EventKey, Key: key})
 }
 
+// HandleEvent passes the event on to the pane's widget and redraws the pane
 func (gp *GoPane) HandleEvent(ev termbox.Event) {
-	if gp.IsEditable() {
-		gp.editBox.HandleEvent(ev)
-	} else {
-		gp.HandleKey(ev.Key)
+	if gp.isSplit() || gp.widget == nil {
+		return
+	}
+	if ev.Type == termbox.EventMouse {
+		// make the coordinates relative to the widget
+		x, y, _, _ := gp.contentArea()
+		ev.MouseX -= x
+		ev.MouseY -= y
+	}
+	gp.widget.HandleEvent(ev)
+	gp.Refresh()
+}
+
+// ScrollUp scrolls the pane's content log back by the given number of rows
+func (gp *GoPane) ScrollUp(rows int) {
+	if log := gp.ContentLog(); log != nil {
+		log.ScrollUp(rows)
+		gp.Refresh()
+	}
+}
+
+// ScrollDown scrolls the pane's content log forward by the given number of rows
+func (gp *GoPane) ScrollDown(rows int) {
+	if log := gp.ContentLog(); log != nil {
+		log.ScrollDown(rows)
+		gp.Refresh()
+	}
+}
+
+// ScrollToBottom makes the pane's content log follow new content again
+func (gp *GoPane) ScrollToBottom() {
+	if log := gp.ContentLog(); log != nil {
+		log.ScrollToBottom()
+		gp.Refresh()
+	}
+}
+
+func (gp *GoPane) ScrollLeft(cols int) {
+	if log := gp.ContentLog(); log != nil {
+		log.ScrollLeft(cols)
+		gp.Refresh()
+	}
+}
+
+func (gp *GoPane) ScrollRight(cols int) {
+	if log := gp.ContentLog(); log != nil {
+		log.ScrollRight(cols)
+		gp.Refresh()
 	}
 }
 
 func (gp *GoPane) IsEditable() bool {
-	return gp.editBox != nil
+	return gp.editBox() != nil
 }
 
 func (gp *GoPane) MakeEditable() {
-	gp.editBox = NewEditBox(gp.x, gp.y, gp.width, gp.height, nil)
+	x, y, width, height := gp.contentArea()
+	gp.SetWidget(NewEditBox(x, y, width, height, nil))
 }
 
 func (gp *GoPane) IsAlive() bool {
 	// if it's editable, return edit alive state, otherwise true
-	return (gp.IsEditable() && gp.editBox.Alive()) || !gp.IsEditable()
+	return !gp.IsEditable() || gp.editBox().Alive()
 }
 
 // If the goPane is editable, get the next line from it
 func (gp *GoPane) GetLine() string {
 	if gp.IsEditable() {
-		return string(gp.editBox.GetLine())
+		return string(gp.editBox().GetLine())
 	}
 	// TODO should this have a better failure mode?
 	return ""
@@ -259,16 +457,26 @@ func (gp *GoPane) GetLine() string {
 
 func (gp *GoPane) ChangePrompt(colorStrs []ColorStr) {
 	if gp.IsEditable() {
-		gp.editBox.ChangePrompt(colorStrs)
+		gp.editBox().ChangePrompt(colorStrs)
+	}
+}
+
+// ChangePromptMarkup is ChangePrompt for a markup string (see ParseMarkup)
+func (gp *GoPane) ChangePromptMarkup(markup string) error {
+	colorStrs, err := ParseMarkup(markup)
+	if err != nil {
+		return err
 	}
+	gp.ChangePrompt(colorStrs)
+	return nil
 }
 func (gp *GoPane) Info() {
 	fmt.Printf("Pane is ")
 	if !gp.isVertical {
 		fmt.Printf("not ")
 	}
-	fmt.Printf("vertical, split at", gp.splitLocation, "at coords", gp.x, gp.y)
-	fmt.Printf(" and is", gp.width, "wide and", gp.height, "tall\n")
+	fmt.Printf("vertical, split at %d at coords %d, %d", gp.splitLocation, gp.x, gp.y)
+	fmt.Printf(" and is %d wide and %d tall\n", gp.width, gp.height)
 }
 
 // splits a pane horizonally at the given line
@@ -280,15 +488,7 @@ func (gp *GoPane) Horiz(y int) bool {
 	}
 	gp.isVertical = false
 	gp.splitLocation = y
-	absSplit := y
-	if absSplit < 0 {
-		absSplit += gp.height
-	}
-	gp.First = NewGoPane(gp.width, absSplit-1, gp.x, gp.y)
-	gp.Second = NewGoPane(gp.width, gp.height-(absSplit+1), gp.x, gp.y+absSplit)
-	// the First pane inherits the split content
-	gp.First.content = gp.content
-	gp.content = nil
+	gp.split()
 	return true
 }
 
@@ -301,29 +501,97 @@ func (gp *GoPane) Vert(x int) bool {
 	}
 	gp.isVertical = true
 	gp.splitLocation = x
-	absSplit := x
-	if absSplit < 0 {
-		absSplit += gp.width
-	}
-	gp.First = NewGoPane(absSplit, gp.height, gp.x, gp.y)
-	gp.Second = NewGoPane(gp.width-(absSplit+1), gp.height, gp.x+absSplit+1, gp.y)
-	// the First pane inherits the split content
-	gp.First.content = gp.content
-	gp.content = nil
+	gp.split()
 	return true
 }
 
-func (gp *GoPane) AddLine(colorStrs []ColorStr) {
-	if gp.isSplit() {
-		gp.First.AddLine(colorStrs)
+// split creates the children once the split direction and location are set
+func (gp *GoPane) split() {
+	gp.First = NewGoPane(0, 0, gp.x, gp.y)
+	gp.Second = NewGoPane(0, 0, gp.x, gp.y)
+	gp.First.ui = gp.ui
+	gp.Second.ui = gp.ui
+	// the First pane inherits the split content
+	gp.First.widget = gp.widget
+	gp.First.isFocused = gp.isFocused
+	gp.First.title = gp.title
+	gp.First.titleStatus = gp.titleStatus
+	gp.First.process = gp.process
+	gp.First.watcher = gp.watcher
+	gp.widget = nil
+	gp.isFocused = false
+	gp.title = ""
+	gp.titleStatus = ""
+	gp.process = nil
+	gp.watcher = nil
+	gp.layout()
+}
+
+// splitOffset returns the location of the divider relative to the pane
+func (gp *GoPane) splitOffset() int {
+	size := gp.height
+	if gp.isVertical {
+		size = gp.width
+	}
+	offset := gp.splitLocation
+	if offset < 0 {
+		offset += size
+	}
+	// keep the divider inside the pane if it shrank
+	if offset >= size {
+		offset = size - 1
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return offset
+}
+
+// layout sizes and positions the children on either side of the divider
+func (gp *GoPane) layout() {
+	if !gp.isSplit() {
+		return
+	}
+	split := gp.splitOffset()
+	if gp.isVertical {
+		gp.First.setBounds(gp.x, gp.y, split, gp.height)
+		gp.Second.setBounds(gp.x+split+1, gp.y, gp.width-split-1, gp.height)
 	} else {
-		gp.content = append(gp.content, colorStrs)
+		gp.First.setBounds(gp.x, gp.y, gp.width, split)
+		gp.Second.setBounds(gp.x, gp.y+split+1, gp.width, gp.height-split-1)
+	}
+}
+
+// setBounds moves and resizes the pane, laying out its children again
+func (gp *GoPane) setBounds(x, y, width, height int) {
+	gp.x, gp.y, gp.width, gp.height = x, y, width, height
+	gp.layout()
+}
+
+// AddLine appends a line to the content. The returned handle can be used to
+// change the line later.
+func (gp *GoPane) AddLine(colorStrs []ColorStr) LineHandle {
+	if log := gp.ContentLog(); log != nil {
+		return log.AddLine(colorStrs)
 	}
+	return LineHandle{}
+}
+
+// AddMarkup is AddLine for a markup string (see ParseMarkup)
+func (gp *GoPane) AddMarkup(markup string) error {
+	colorStrs, err := ParseMarkup(markup)
+	if err != nil {
+		return err
+	}
+	gp.AddLine(colorStrs)
+	return nil
 }
 
 // deletes all content
 func (gp *GoPane) Clear() {
-	gp.content = nil
+	if log := gp.ContentLog(); log != nil {
+		log.Clear()
+	}
 }
 
 func (gp *GoPane) GetFocusedChild() *GoPane {
@@ -354,111 +622,150 @@ func (gp *GoPane) focusChild(target *GoPane) {
 
 // The focus, focusChild, and unfocus functions are ONLY for the GoPaneUi class to manipulate
 func (gp *GoPane) focus() {
-	// move the cursor to the output start
 	if gp.isSplit() {
 		gp.First.focus()
 		gp.Second.unfocus()
-	} else if gp.IsEditable() {
-		gp.editBox.Focus()
-		gp.editBox.Refresh()
 	} else {
-		termbox.SetCursor(gp.x, gp.y)
+		// widgets that want a cursor show it when they're drawn
+		termbox.HideCursor()
+		gp.widget.Focus()
 	}
 	gp.isFocused = true
 }
 
 func (gp *GoPane) unfocus() {
-	// move the cursor to the output start
-	if gp.IsEditable() {
-		gp.editBox.UnFocus()
-		gp.editBox.Refresh()
+	if !gp.isSplit() && gp.isFocused {
+		gp.widget.Blur()
 	}
 	gp.isFocused = false
 }
 
-// TODO move somewhere useful possibly
-func getOutputWidth(src []ColorRune) int {
-	width := 0
-	for _, colorRune := range src {
-		// TODO tab handling
-		if strconv.IsPrint(colorRune.r) {
-			width++
-		}
+// rerenders all content in the given pane
+func (gp *GoPane) Refresh() {
+	// drawing is done under the lock too, so that panes refreshed from
+	// different goroutines don't flush each other's half-drawn content
+	termboxMutex.Lock()
+	defer termboxMutex.Unlock()
+	gp.render()
+	drawScreen.Flush()
+}
+
+// render draws the pane with the overlay on top. The caller must hold
+// termboxMutex.
+func (gp *GoPane) render() {
+	gp.draw()
+	gp.drawDividers()
+	// keep the overlay on top of the panes
+	if gp.ui != nil && gp.ui.overlay != nil {
+		gp.ui.drawOverlay()
 	}
-	return width
 }
 
-// rerenders all content in the given pane
-func (gp *GoPane) Refresh() {
+func (gp *GoPane) theme() *Theme {
+	if gp.ui == nil {
+		return defaultTheme()
+	}
+	return gp.ui.currentTheme()
+}
+
+// draws the content of all the leaves under gp
+func (gp *GoPane) draw() {
 	if gp.isSplit() {
 		// in-order traversal of child panes
-		gp.First.Refresh()
-		// TODO custom borders
-		if gp.isVertical {
-			absSplit := gp.splitLocation
-			if gp.splitLocation < 0 {
-				absSplit = gp.splitLocation + gp.width
-			}
-			for y := gp.y; y <= gp.y+gp.height; y++ {
-				termbox.SetCell(gp.x+absSplit-1, y, '|', termbox.ColorWhite, tcd)
-			}
-		} else {
-			absSplit := gp.splitLocation
-			if gp.splitLocation < 0 {
-				absSplit = gp.splitLocation + gp.height
-			}
-			for x := gp.x; x <= gp.x+gp.width; x++ {
-				termbox.SetCell(x, gp.y+absSplit-1, '─', termbox.ColorWhite, tcd)
-			}
-		}
-		gp.Second.Refresh()
+		gp.First.draw()
+		gp.Second.draw()
+		return
+	}
+	// it's a leaf pane, so render its widget
+	x, y, width, height := gp.contentArea()
+	gp.widget.Draw(NewCanvas(x, y, width, height))
+	if gp.theme().DimUnfocused && !gp.isFocused {
+		dimArea(x, y, width, height)
+	}
+	if gp.title != "" {
+		gp.drawTitle()
+	}
+}
+
+// a divider between the two children of a split pane
+type divider struct {
+	x, y       int
+	length     int
+	isVertical bool
+}
+
+func (gp *GoPane) dividers() []divider {
+	if !gp.isSplit() {
+		return nil
+	}
+	split := gp.splitOffset()
+	var d divider
+	if gp.isVertical {
+		d = divider{x: gp.x + split, y: gp.y, length: gp.height, isVertical: true}
 	} else {
-		// it's a leaf pane, so render its content
-		// First, create a byte buffer of the output
-		buf := make([][]ColorRune, len(gp.content))
-		col := 0
-		bufRow := 0
-		for _, row := range gp.content {
-			for _, colorStr := range row {
-				for _, char := range colorStr.str {
-					if strconv.IsPrint(char) {
-						// if it's not printable, don't add it to the width
-						col++
-					}
-					// handle line wrapping
-					if col >= gp.width || char == '\n' {
-						col = 0
-						bufRow++
-						buf = append(buf, make([]ColorRune, 0))
-					}
-					if char != '\n' {
-						buf[bufRow] = append(buf[bufRow], ColorRune{
-							r: char, fg: colorStr.fg, bg: colorStr.bg})
-					}
-				}
+		d = divider{x: gp.x, y: gp.y + split, length: gp.width}
+	}
+	return append(append([]divider{d}, gp.First.dividers()...), gp.Second.dividers()...)
+}
+
+// isAdjacent reports whether the cell touches the pane's edge or corners
+func (gp *GoPane) isAdjacent(x, y int) bool {
+	left, right := gp.x-1, gp.x+gp.width
+	top, bottom := gp.y-1, gp.y+gp.height
+	if x < left || x > right || y < top || y > bottom {
+		return false
+	}
+	return x == left || x == right || y == top || y == bottom
+}
+
+// draws the dividers under gp, joining them where they meet
+func (gp *GoPane) drawDividers() {
+	dividers := gp.dividers()
+	if len(dividers) == 0 {
+		return
+	}
+	type cell struct{ x, y int }
+	cells := make(map[cell]uint8)
+	for _, d := range dividers {
+		for i := 0; i < d.length; i++ {
+			if d.isVertical {
+				cells[cell{d.x, d.y + i}] |= dirUp | dirDown
+			} else {
+				cells[cell{d.x + i, d.y}] |= dirLeft | dirRight
 			}
-			col = 0
-			bufRow++
 		}
-		// set the cells in the termbox buffer (or at least, all that can fit)
-		startRow := len(buf) - gp.height
-		if startRow < 0 {
-			startRow = 0
+	}
+	// a divider ending on another one turns that cell into a junction
+	for _, d := range dividers {
+		var before, after cell
+		var towardBefore, towardAfter uint8
+		if d.isVertical {
+			before, after = cell{d.x, d.y - 1}, cell{d.x, d.y + d.length}
+			towardBefore, towardAfter = dirDown, dirUp
+		} else {
+			before, after = cell{d.x - 1, d.y}, cell{d.x + d.length, d.y}
+			towardBefore, towardAfter = dirRight, dirLeft
+		}
+		if _, ok := cells[before]; ok {
+			cells[before] |= towardBefore
 		}
-		for rownum, row := range buf[startRow:] {
-			for charnum, colorRune := range row {
-				termbox.SetCell(gp.x+charnum, gp.y+rownum, colorRune.r, colorRune.fg, colorRune.bg)
-			}
-			for spaceStart := getOutputWidth(row); spaceStart < gp.width; spaceStart++ {
-				termbox.SetCell(gp.x+spaceStart, gp.y+rownum, ' ', tcd, tcd)
-			}
+		if _, ok := cells[after]; ok {
+			cells[after] |= towardAfter
 		}
-		// set all empty rows as spaces
-		for row := len(buf); row < gp.height; row++ {
-			for idx := 0; idx < gp.width; idx++ {
-				termbox.SetCell(gp.x+idx, gp.y+row, ' ', tcd, tcd)
-			}
+	}
+
+	theme := gp.theme()
+	var focused *GoPane
+	if gp.ui != nil {
+		focused = gp.ui.GetFocusedPane()
+	}
+	normalFg, normalBg := theme.DividerStyle.attributes()
+	focusedFg, focusedBg := theme.FocusedDividerStyle.attributes()
+	for c, dirs := range cells {
+		fg, bg := normalFg, normalBg
+		if focused != nil && focused.isAdjacent(c.x, c.y) {
+			fg, bg = focusedFg, focusedBg
 		}
+		drawScreen.SetCell(c.x, c.y, theme.dividerGlyph(dirs), fg, bg)
 	}
-	TermboxSafeFlush()
 }
@@ -5,33 +5,10 @@ package gopanes
 import (
 	"github.com/mattn/go-runewidth"
 	"github.com/nsf/termbox-go"
+	"strings"
 	"unicode/utf8"
 )
 
-func tbprint(x, y int, fg, bg termbox.Attribute, msg string) {
-	for _, c := range msg {
-		termbox.SetCell(x, y, c, fg, bg)
-		x += runewidth.RuneWidth(c)
-	}
-}
-
-func tbprint_color(x, y int, colorStrs []ColorStr) {
-	for _, colorStr := range colorStrs {
-		for _, c := range colorStr.str {
-			termbox.SetCell(x, y, c, colorStr.fg, colorStr.bg)
-			x += runewidth.RuneWidth(c)
-		}
-	}
-}
-
-func fill(x, y, w, h int, cell termbox.Cell) {
-	for ly := 0; ly < h; ly++ {
-		for lx := 0; lx < w; lx++ {
-			termbox.SetCell(x+lx, y+ly, cell.Ch, cell.Fg, cell.Bg)
-		}
-	}
-}
-
 func rune_advance_len(r rune, pos int) int {
 	if r == '\t' {
 		return tabstop_length - pos%tabstop_length
@@ -100,22 +77,24 @@ func (eb *EditBox) rawPromptText() []byte {
 	var rawPrompt []byte
 	for _, colorStr := range eb.prompt {
 		// append all the bytes of the string
-		rawPrompt = append(rawPrompt, []byte(colorStr.str)...)
+		rawPrompt = append(rawPrompt, []byte(colorStr.Str)...)
 	}
 	return rawPrompt
 }
 
-// Draws the EditBox in the given location, 'h' is not used at the moment
+// Draws the EditBox on the given canvas, only the first row is used at the
+// moment
 // TODO fix issue with prompt fragments remaining when a redraw makes it
 //  shorter
-func (eb *EditBox) Draw() {
+func (eb *EditBox) Draw(c *Canvas) {
+	eb.x, eb.y, eb.width, eb.height = c.x, c.y, c.Width(), c.Height()
 	eb.AdjustVOffset(eb.width)
 
-	const coldef = termbox.ColorDefault
-	fill(eb.x, eb.y, eb.width, eb.height, termbox.Cell{Ch: ' '})
+	coldef := NewStyle()
+	c.Clear(coldef)
 
 	// render the prompt
-	tbprint_color(eb.x, eb.y, eb.prompt)
+	c.PrintColorStrs(0, 0, eb.prompt)
 
 	// get prompt dimensions
 	raw_prompt := eb.rawPromptText()
@@ -136,8 +115,7 @@ func (eb *EditBox) Draw() {
 		}
 
 		if rx >= eb.width {
-			termbox.SetCell(eb.x+eb.width-1, eb.y, '→',
-				coldef, coldef)
+			c.SetCell(eb.width-1, 0, glyphs().rightArrow, coldef)
 			break
 		}
 
@@ -150,27 +128,25 @@ func (eb *EditBox) Draw() {
 				}
 
 				if rx >= prompt_voffset {
-					termbox.SetCell(eb.x+rx, eb.y, ' ', coldef, coldef)
+					c.SetCell(rx, 0, ' ', coldef)
 				}
 			}
 		} else {
 			if rx >= prompt_voffset {
-				termbox.SetCell(eb.x+rx, eb.y, r, coldef, coldef)
+				c.SetCell(rx, 0, r, coldef)
 			}
 			lx += runewidth.RuneWidth(r)
 		}
 	next:
 		t = t[size:]
 	}
-	if rx < eb.width {
-		for ; rx < eb.width; rx++ {
-			termbox.SetCell(eb.x+rx, eb.y, ' ', coldef, coldef)
-		}
-	}
 	// TODO fill in blank space so prompt resizing works
 
 	if eb.line_voffset != 0 {
-		termbox.SetCell(eb.x+prompt_voffset, eb.y, '←', coldef, coldef)
+		c.SetCell(prompt_voffset, 0, glyphs().leftArrow, coldef)
+	}
+	if eb.isFocused {
+		c.SetCursor(eb.CursorX(), 0)
 	}
 }
 
@@ -265,6 +241,18 @@ func (eb *EditBox) InsertRune(r rune) {
 	eb.MoveCursorOneRuneForward()
 }
 
+// InsertText inserts text at the cursor. The box holds a single line, so line
+// breaks become spaces.
+func (eb *EditBox) InsertText(text string) {
+	text = strings.Replace(text, "\r\n", "\n", -1)
+	for _, r := range text {
+		if r == '\n' || r == '\r' {
+			r = ' '
+		}
+		eb.InsertRune(r)
+	}
+}
+
 // Please, keep in mind that cursor depends on the value of line_voffset, which
 // is being set on Draw() call, so.. call this method after Draw() one.
 func (eb *EditBox) CursorX() int {
@@ -322,19 +310,31 @@ func (eb *EditBox) Focus() {
 	eb.isFocused = true
 }
 
-func (eb *EditBox) UnFocus() {
+func (eb *EditBox) Blur() {
 	eb.isFocused = false
 }
 
+// UnFocus is the old name of Blur
+func (eb *EditBox) UnFocus() {
+	eb.Blur()
+}
+
+// an EditBox is one line of any width
+func (eb *EditBox) PreferredSize() (width, height int) {
+	return 0, 1
+}
+
 func (eb *EditBox) Refresh() {
-	eb.Draw()
-	if eb.isFocused {
-		termbox.SetCursor(eb.CursorX(), eb.y)
-	}
-	TermboxSafeFlush()
+	termboxMutex.Lock()
+	defer termboxMutex.Unlock()
+	eb.Draw(NewCanvas(eb.x, eb.y, eb.width, eb.height))
+	drawScreen.Flush()
 }
 
 func (eb *EditBox) HandleEvent(ev termbox.Event) {
+	if ev.Type == termbox.EventMouse {
+		return
+	}
 	switch ev.Key {
 	case termbox.KeyEsc: //TODO this system is basically obselete now
 		eb.Kill()
@@ -376,7 +376,7 @@ func (eb *EditBox) HandleEvent(ev termbox.Event) {
 
 func NewEditBox(x, y, width, height int, prompt []ColorStr) *EditBox {
 	eb := EditBox{x: x, y: y, width: width, height: height, output: make(chan []byte), prompt: prompt}
-	eb.Draw()
+	eb.Draw(NewCanvas(x, y, width, height))
 	// listen for input
 	return &eb
 }
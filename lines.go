@@ -0,0 +1,278 @@
+package gopanes
+
+// a line of pane content, along with its wrapped rows from the last time it
+// was drawn
+type contentLine struct {
+	colorStrs []ColorStr
+	log       *ContentLog // the log the line was added to
+	removed   bool        // the line was taken out of its log
+	rows      [][]ColorRune
+	wrapped   wrapParams
+	isWrapped bool
+}
+
+// what a line was wrapped with, so it's only wrapped again when these change
+type wrapParams struct {
+	width  int
+	mode   WrapMode
+	marker rune
+}
+
+func (line *contentLine) wrap(params wrapParams) [][]ColorRune {
+	if !line.isWrapped || line.wrapped != params {
+		line.rows = wrapLine(line.colorStrs, params.width, params.mode, params.marker)
+		line.wrapped = params
+		line.isWrapped = true
+	}
+	return line.rows
+}
+
+func (line *contentLine) set(colorStrs []ColorStr) {
+	line.colorStrs = colorStrs
+	line.isWrapped = false
+}
+
+// A LineHandle refers to a line of a pane's content log. It keeps referring to the
+// same line when lines before it are inserted, deleted or trimmed from the
+// scrollback, so it can be used to update a line in place.
+type LineHandle struct {
+	line *contentLine
+}
+
+// lock locks the line's log, returning false with the log unlocked again if
+// the line has been removed from it since. The line's log never changes, so
+// it can be read before locking, but whether it was removed can't.
+func (h LineHandle) lock() bool {
+	if h.line == nil {
+		return false
+	}
+	h.line.log.contentLock.Lock()
+	if h.line.removed {
+		h.line.log.contentLock.Unlock()
+		return false
+	}
+	return true
+}
+
+func (h LineHandle) unlock() {
+	h.line.log.contentLock.Unlock()
+}
+
+// Valid reports whether the line is still in its log
+func (h LineHandle) Valid() bool {
+	if !h.lock() {
+		return false
+	}
+	h.unlock()
+	return true
+}
+
+// Index returns the line's current index in its log, or -1 if it's gone
+func (h LineHandle) Index() int {
+	if !h.lock() {
+		return -1
+	}
+	defer h.unlock()
+	return h.line.log.indexOf(h.line)
+}
+
+// Set replaces the line's content. It returns false if the line is gone.
+func (h LineHandle) Set(colorStrs []ColorStr) bool {
+	if !h.lock() {
+		return false
+	}
+	defer h.unlock()
+	h.line.set(colorStrs)
+	return true
+}
+
+// Delete removes the line from its log. It returns false if it was gone.
+func (h LineHandle) Delete() bool {
+	if !h.lock() {
+		return false
+	}
+	defer h.unlock()
+	return h.line.log.removeLine(h.line.log.indexOf(h.line))
+}
+
+// the caller must hold contentLock
+func (cl *ContentLog) indexOf(line *contentLine) int {
+	for idx, l := range cl.content {
+		if l == line {
+			return idx
+		}
+	}
+	return -1
+}
+
+// the caller must hold contentLock
+func (cl *ContentLog) removeLine(idx int) bool {
+	if idx < 0 || idx >= len(cl.content) {
+		return false
+	}
+	cl.content[idx].removed = true
+	cl.content = append(cl.content[:idx], cl.content[idx+1:]...)
+	return true
+}
+
+// trimScrollback drops the oldest lines past the scrollback limit. The caller
+// must hold contentLock.
+func (cl *ContentLog) trimScrollback() {
+	if cl.maxLines <= 0 || len(cl.content) <= cl.maxLines {
+		return
+	}
+	extra := len(cl.content) - cl.maxLines
+	for _, line := range cl.content[:extra] {
+		line.removed = true
+	}
+	cl.content = append([]*contentLine(nil), cl.content[extra:]...)
+}
+
+// SetMaxLines limits how many lines the log keeps, dropping the oldest ones
+// once there are more. Zero means no limit.
+func (cl *ContentLog) SetMaxLines(maxLines int) {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	cl.maxLines = maxLines
+	cl.trimScrollback()
+}
+
+// Len returns how many lines the log has
+func (cl *ContentLog) Len() int {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	return len(cl.content)
+}
+
+// Line returns the content of the line at the given index, or nil if there's
+// no such line
+func (cl *ContentLog) Line(idx int) []ColorStr {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	if idx < 0 || idx >= len(cl.content) {
+		return nil
+	}
+	return cl.content[idx].colorStrs
+}
+
+// Handle returns a handle to the line at the given index. The handle isn't
+// valid if there's no such line.
+func (cl *ContentLog) Handle(idx int) LineHandle {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	if idx < 0 || idx >= len(cl.content) {
+		return LineHandle{}
+	}
+	return LineHandle{line: cl.content[idx]}
+}
+
+// SetLine replaces the line at the given index. It returns false if there's
+// no such line.
+func (cl *ContentLog) SetLine(idx int, colorStrs []ColorStr) bool {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	if idx < 0 || idx >= len(cl.content) {
+		return false
+	}
+	cl.content[idx].set(colorStrs)
+	return true
+}
+
+// InsertLine inserts a line before the given index. Indices past the end
+// append the line.
+func (cl *ContentLog) InsertLine(idx int, colorStrs []ColorStr) LineHandle {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	if idx < 0 {
+		idx = 0
+	}
+	if idx > len(cl.content) {
+		idx = len(cl.content)
+	}
+	line := &contentLine{colorStrs: colorStrs, log: cl}
+	cl.content = append(cl.content, nil)
+	copy(cl.content[idx+1:], cl.content[idx:])
+	cl.content[idx] = line
+	cl.trimScrollback()
+	return LineHandle{line: line}
+}
+
+// SetLines replaces all of the log's content at once, so it's never drawn
+// half replaced
+func (cl *ContentLog) SetLines(lines [][]ColorStr) {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	for _, line := range cl.content {
+		line.removed = true
+	}
+	cl.content = make([]*contentLine, len(lines))
+	for idx, colorStrs := range lines {
+		cl.content[idx] = &contentLine{colorStrs: colorStrs, log: cl}
+	}
+	cl.trimScrollback()
+}
+
+// DeleteLine removes the line at the given index. It returns false if there's
+// no such line.
+func (cl *ContentLog) DeleteLine(idx int) bool {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	return cl.removeLine(idx)
+}
+
+// the pane versions of the line methods work on the pane's content log, see
+// GoPane.ContentLog
+
+func (gp *GoPane) SetMaxLines(maxLines int) {
+	if log := gp.ContentLog(); log != nil {
+		log.SetMaxLines(maxLines)
+	}
+}
+
+func (gp *GoPane) Len() int {
+	if log := gp.ContentLog(); log != nil {
+		return log.Len()
+	}
+	return 0
+}
+
+func (gp *GoPane) Line(idx int) []ColorStr {
+	if log := gp.ContentLog(); log != nil {
+		return log.Line(idx)
+	}
+	return nil
+}
+
+func (gp *GoPane) Handle(idx int) LineHandle {
+	if log := gp.ContentLog(); log != nil {
+		return log.Handle(idx)
+	}
+	return LineHandle{}
+}
+
+func (gp *GoPane) SetLine(idx int, colorStrs []ColorStr) bool {
+	if log := gp.ContentLog(); log != nil {
+		return log.SetLine(idx, colorStrs)
+	}
+	return false
+}
+
+func (gp *GoPane) InsertLine(idx int, colorStrs []ColorStr) LineHandle {
+	if log := gp.ContentLog(); log != nil {
+		return log.InsertLine(idx, colorStrs)
+	}
+	return LineHandle{}
+}
+
+func (gp *GoPane) SetLines(lines [][]ColorStr) {
+	if log := gp.ContentLog(); log != nil {
+		log.SetLines(lines)
+	}
+}
+
+func (gp *GoPane) DeleteLine(idx int) bool {
+	if log := gp.ContentLog(); log != nil {
+		return log.DeleteLine(idx)
+	}
+	return false
+}
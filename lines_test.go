@@ -0,0 +1,81 @@
+package gopanes
+
+import (
+	"reflect"
+	"testing"
+)
+
+// logText returns the text of each of the log's lines
+func logText(cl *ContentLog) []string {
+	var text []string
+	for idx := 0; idx < cl.Len(); idx++ {
+		text = append(text, lineText(cl.Line(idx)))
+	}
+	return text
+}
+
+func TestLineHandlesSurviveTrimming(t *testing.T) {
+	cl := NewContentLog()
+	cl.SetMaxLines(3)
+	first := cl.AddLine([]ColorStr{Color.Default("a")})
+	cl.AddLine([]ColorStr{Color.Default("b")})
+	kept := cl.AddLine([]ColorStr{Color.Default("c")})
+	cl.AddLine([]ColorStr{Color.Default("d")})
+	cl.AddLine([]ColorStr{Color.Default("e")})
+	if first.Valid() || first.Index() != -1 || first.Set([]ColorStr{Color.Default("x")}) || first.Delete() {
+		t.Error("the trimmed line's handle still works")
+	}
+	if !kept.Valid() || kept.Index() != 0 {
+		t.Fatalf("kept line is valid %v at %d, want valid at 0", kept.Valid(), kept.Index())
+	}
+	kept.Set([]ColorStr{Color.Default("C")})
+	if got, want := logText(cl), []string{"C", "d", "e"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+	if !kept.Delete() || kept.Valid() || kept.Delete() {
+		t.Error("the deleted line's handle still works")
+	}
+	if got, want := logText(cl), []string{"d", "e"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after deleting, lines = %q, want %q", got, want)
+	}
+}
+
+func TestLineEditing(t *testing.T) {
+	cl := NewContentLog()
+	cl.AddLine([]ColorStr{Color.Default("b")})
+	handle := cl.InsertLine(0, []ColorStr{Color.Default("a")})
+	cl.InsertLine(10, []ColorStr{Color.Default("d")})
+	cl.InsertLine(2, []ColorStr{Color.Default("c")})
+	if got, want := logText(cl), []string{"a", "b", "c", "d"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("after inserting, lines = %q, want %q", got, want)
+	}
+	if !cl.SetLine(1, []ColorStr{Color.Default("B")}) || cl.SetLine(4, nil) || cl.SetLine(-1, nil) {
+		t.Error("SetLine succeeded or failed wrongly")
+	}
+	if !cl.DeleteLine(0) || cl.DeleteLine(3) {
+		t.Error("DeleteLine succeeded or failed wrongly")
+	}
+	if got, want := logText(cl), []string{"B", "c", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+	if handle.Valid() {
+		t.Error("the handle of a deleted line is valid")
+	}
+	cl.Clear()
+	if cl.Len() != 0 || cl.Handle(0).Valid() {
+		t.Error("lines left after clearing")
+	}
+}
+
+func TestSetLines(t *testing.T) {
+	cl := NewContentLog()
+	old := cl.AddLine([]ColorStr{Color.Default("old")})
+	cl.SetMaxLines(2)
+	cl.SetLines([][]ColorStr{{Color.Default("a")}, {Color.Default("b")}, {Color.Default("c")}})
+	if got, want := logText(cl), []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+	if old.Valid() || !cl.Handle(0).Valid() {
+		t.Error("handles to the old lines are still valid or the new ones aren't")
+	}
+}
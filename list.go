@@ -0,0 +1,334 @@
+package gopanes
+
+import (
+	"fmt"
+	"github.com/nsf/termbox-go"
+	"strings"
+	"sync"
+	"time"
+)
+
+// a pause this long between keys starts a new type-to-jump search
+const jumpTimeout = time.Second
+
+type ListItem struct {
+	Text  []ColorStr
+	Value interface{} // anything the program wants to associate with the item
+}
+
+// plainText returns the item's text without styles
+func (item ListItem) plainText() string {
+	return lineText(item.Text)
+}
+
+// List is a widget for picking items from a list. The arrow keys, j and k,
+// PgUp/PgDn and Home/End move the cursor, typing other characters jumps to
+// the next item starting with them, and Enter or clicking on the item under
+// the cursor selects it. In multi-select mode, Space and clicking toggle
+// items and Enter selects all the toggled ones.
+type List struct {
+	items       []ListItem
+	itemsLock   sync.Mutex
+	cursor      int
+	offset      int // index of the first visible item
+	height      int // the height the list was last drawn at
+	multiSelect bool
+	toggled     map[int]bool
+	isFocused   bool
+	jumpText    string
+	lastJump    time.Time
+	onSelect    func(items []ListItem)
+	selections  chan []ListItem
+
+	CursorStyle Style
+	// the style of the check boxes of toggled items in multi-select mode
+	ToggledStyle Style
+}
+
+func NewList(items ...ListItem) *List {
+	return &List{
+		items:        items,
+		toggled:      make(map[int]bool),
+		CursorStyle:  NewStyle().Reverse(),
+		ToggledStyle: NewStyle().Fg(ColorGreen).Bold(),
+	}
+}
+
+// AddItem appends an item with the given text and value
+func (l *List) AddItem(text []ColorStr, value interface{}) {
+	l.itemsLock.Lock()
+	defer l.itemsLock.Unlock()
+	l.items = append(l.items, ListItem{Text: text, Value: value})
+}
+
+// SetItems replaces all the items, moving the cursor back to the top
+func (l *List) SetItems(items []ListItem) {
+	l.itemsLock.Lock()
+	defer l.itemsLock.Unlock()
+	l.items = items
+	l.cursor, l.offset = 0, 0
+	l.toggled = make(map[int]bool)
+}
+
+func (l *List) Items() []ListItem {
+	l.itemsLock.Lock()
+	defer l.itemsLock.Unlock()
+	return append([]ListItem(nil), l.items...)
+}
+
+// Cursor returns the index of the item under the cursor
+func (l *List) Cursor() int {
+	return l.cursor
+}
+
+// SetCursor moves the cursor to the item at the given index
+func (l *List) SetCursor(idx int) {
+	l.itemsLock.Lock()
+	defer l.itemsLock.Unlock()
+	l.moveCursor(idx)
+}
+
+// the caller must hold itemsLock
+func (l *List) moveCursor(idx int) {
+	if idx >= len(l.items) {
+		idx = len(l.items) - 1
+	}
+	if idx < 0 {
+		idx = 0
+	}
+	l.cursor = idx
+}
+
+// SetMultiSelect switches between picking a single item and several
+func (l *List) SetMultiSelect(multiSelect bool) {
+	l.multiSelect = multiSelect
+	l.toggled = make(map[int]bool)
+}
+
+// OnSelect sets a function called with the selected items when the user
+// makes a selection. It's called from the UI's event loop, so it shouldn't
+// block.
+func (l *List) OnSelect(onSelect func(items []ListItem)) {
+	l.onSelect = onSelect
+}
+
+// Selections returns a channel the selections are also sent on. Selections
+// made while the channel is full are dropped rather than blocking the UI.
+func (l *List) Selections() <-chan []ListItem {
+	if l.selections == nil {
+		l.selections = make(chan []ListItem, 16)
+	}
+	return l.selections
+}
+
+// the caller must hold itemsLock
+func (l *List) toggle(idx int) {
+	if idx < 0 || idx >= len(l.items) {
+		return
+	}
+	if l.toggled[idx] {
+		delete(l.toggled, idx)
+	} else {
+		l.toggled[idx] = true
+	}
+}
+
+// selection returns the items to report when the user picks them. The caller
+// must hold itemsLock.
+func (l *List) selection() []ListItem {
+	var selected []ListItem
+	if l.multiSelect {
+		for idx, item := range l.items {
+			if l.toggled[idx] {
+				selected = append(selected, item)
+			}
+		}
+	} else if l.cursor < len(l.items) {
+		selected = []ListItem{l.items[l.cursor]}
+	}
+	return selected
+}
+
+// report hands a selection to the callback and the channel. It's called
+// without itemsLock so the callback can change the list.
+func (l *List) report(selected []ListItem) {
+	if len(selected) == 0 {
+		return
+	}
+	if l.onSelect != nil {
+		l.onSelect(selected)
+	}
+	if l.selections != nil {
+		select {
+		case l.selections <- selected:
+		default:
+		}
+	}
+}
+
+// jumpTo moves the cursor to the next item starting with the typed text. The
+// caller must hold itemsLock.
+func (l *List) jumpTo(ch rune) {
+	now := time.Now()
+	if now.Sub(l.lastJump) > jumpTimeout {
+		l.jumpText = ""
+	}
+	l.lastJump = now
+	l.jumpText += strings.ToLower(string(ch))
+	// a fresh search starts after the cursor, a continued one includes it
+	start := l.cursor
+	if len([]rune(l.jumpText)) == 1 {
+		start++
+	}
+	for i := 0; i < len(l.items); i++ {
+		idx := (start + i) % len(l.items)
+		if strings.HasPrefix(strings.ToLower(l.items[idx].plainText()), l.jumpText) {
+			l.cursor = idx
+			return
+		}
+	}
+}
+
+func (l *List) HandleEvent(ev termbox.Event) {
+	l.itemsLock.Lock()
+	selected := l.handleEvent(ev)
+	l.itemsLock.Unlock()
+	l.report(selected)
+}
+
+// handleEvent handles an event, returning the items picked if any. The caller
+// must hold itemsLock.
+func (l *List) handleEvent(ev termbox.Event) []ListItem {
+	if ev.Type == termbox.EventMouse {
+		return l.handleMouse(ev)
+	}
+	switch ev.Key {
+	case termbox.KeyArrowUp:
+		l.moveCursor(l.cursor - 1)
+	case termbox.KeyArrowDown:
+		l.moveCursor(l.cursor + 1)
+	case termbox.KeyPgup:
+		l.moveCursor(l.cursor - l.height)
+	case termbox.KeyPgdn:
+		l.moveCursor(l.cursor + l.height)
+	case termbox.KeyHome:
+		l.moveCursor(0)
+	case termbox.KeyEnd:
+		l.moveCursor(len(l.items) - 1)
+	case termbox.KeyEnter:
+		return l.selection()
+	case termbox.KeySpace:
+		if l.multiSelect {
+			l.toggle(l.cursor)
+		} else {
+			l.jumpTo(' ')
+		}
+	default:
+		switch ev.Ch {
+		case 0:
+		case 'k':
+			l.moveCursor(l.cursor - 1)
+		case 'j':
+			l.moveCursor(l.cursor + 1)
+		default:
+			l.jumpTo(ev.Ch)
+		}
+	}
+	return nil
+}
+
+// handleMouse returns the items picked by a click, if any. The caller must
+// hold itemsLock.
+func (l *List) handleMouse(ev termbox.Event) []ListItem {
+	switch ev.Key {
+	case termbox.MouseWheelUp:
+		l.moveCursor(l.cursor - 3)
+	case termbox.MouseWheelDown:
+		l.moveCursor(l.cursor + 3)
+	case termbox.MouseLeft:
+		idx := l.offset + ev.MouseY
+		// clicks above the list, on the pane's title, are ignored
+		if ev.MouseY < 0 || idx >= len(l.items) {
+			return nil
+		}
+		// clicking the item under the cursor picks it
+		if idx == l.cursor {
+			if l.multiSelect {
+				l.toggle(idx)
+			} else {
+				return l.selection()
+			}
+		}
+		l.cursor = idx
+	}
+	return nil
+}
+
+func (l *List) Focus() { l.isFocused = true }
+func (l *List) Blur()  { l.isFocused = false }
+
+func (l *List) checkBoxWidth() int {
+	if l.multiSelect {
+		return 4
+	}
+	return 0
+}
+
+// PreferredSize is the width of the widest item and the number of items
+func (l *List) PreferredSize() (width, height int) {
+	l.itemsLock.Lock()
+	defer l.itemsLock.Unlock()
+	for _, item := range l.items {
+		if w := colorStrsWidth(item.Text) + l.checkBoxWidth(); w > width {
+			width = w
+		}
+	}
+	return width, len(l.items)
+}
+
+// ScrollPosition shows which item the cursor is on
+func (l *List) ScrollPosition() string {
+	l.itemsLock.Lock()
+	defer l.itemsLock.Unlock()
+	if len(l.items) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("[%d/%d]", l.cursor+1, len(l.items))
+}
+
+func (l *List) Draw(c *Canvas) {
+	l.itemsLock.Lock()
+	defer l.itemsLock.Unlock()
+	l.height = c.Height()
+	c.Clear(NewStyle())
+	l.moveCursor(l.cursor)
+	// scroll just enough to keep the cursor visible
+	if l.cursor < l.offset {
+		l.offset = l.cursor
+	}
+	if l.cursor >= l.offset+l.height {
+		l.offset = l.cursor - l.height + 1
+	}
+	for row := 0; row < l.height && l.offset+row < len(l.items); row++ {
+		idx := l.offset + row
+		text := l.items[idx].Text
+		if idx == l.cursor && l.isFocused {
+			// the cursor row is drawn entirely in the cursor style
+			c.Fill(0, row, c.Width(), 1, ' ', l.CursorStyle)
+			text = make([]ColorStr, len(l.items[idx].Text))
+			for i, colorStr := range l.items[idx].Text {
+				text[i] = inheritStyle(colorStr.Style, l.CursorStyle).Str(colorStr.Str)
+			}
+		}
+		if l.multiSelect {
+			box := "[ ] "
+			style := NewStyle()
+			if l.toggled[idx] {
+				box = "[x] "
+				style = l.ToggledStyle
+			}
+			c.Print(0, row, box, style)
+		}
+		c.PrintColorStrs(l.checkBoxWidth(), row, text)
+	}
+}
@@ -0,0 +1,162 @@
+package gopanes
+
+import (
+	"github.com/nsf/termbox-go"
+	"reflect"
+	"testing"
+)
+
+func TestListSelectCanChangeList(t *testing.T) {
+	l := NewList(ListItem{Text: []ColorStr{Color.Default("open")}})
+	// a menu replacing its items when one is picked
+	l.OnSelect(func(items []ListItem) {
+		l.SetItems([]ListItem{{Text: []ColorStr{Color.Default("back")}}})
+	})
+	l.HandleEvent(termbox.Event{Type: termbox.EventKey, Key: termbox.KeyEnter})
+	if items := l.Items(); len(items) != 1 || items[0].plainText() != "back" {
+		t.Errorf("items = %v", items)
+	}
+}
+
+func TestListIgnoresClicksAbove(t *testing.T) {
+	var items []ListItem
+	for _, text := range []string{"a", "b", "c", "d"} {
+		items = append(items, ListItem{Text: []ColorStr{Color.Default(text)}})
+	}
+	l := NewList(items...)
+	l.cursor, l.offset = 2, 2
+	selected := false
+	l.OnSelect(func([]ListItem) { selected = true })
+	// a click on the pane's title row
+	l.HandleEvent(termbox.Event{Type: termbox.EventMouse, Key: termbox.MouseLeft, MouseY: -1})
+	if l.Cursor() != 2 || selected {
+		t.Errorf("cursor moved to %d, selected %v", l.Cursor(), selected)
+	}
+}
+
+// newTestList returns a list of single letter items drawn three rows high
+func newTestList(texts ...string) *List {
+	var items []ListItem
+	for idx, text := range texts {
+		items = append(items, ListItem{Text: []ColorStr{Color.Default(text)}, Value: idx})
+	}
+	l := NewList(items...)
+	l.Draw(newCanvasOn(newMemoryScreen(10, 3), 0, 0, 10, 3))
+	return l
+}
+
+func TestListMovement(t *testing.T) {
+	key := func(k termbox.Key) termbox.Event { return termbox.Event{Type: termbox.EventKey, Key: k} }
+	tests := []struct {
+		name   string
+		events []termbox.Event
+		want   int
+	}{
+		{"down", []termbox.Event{key(termbox.KeyArrowDown), key(termbox.KeyArrowDown)}, 2},
+		{"j and k", []termbox.Event{keyEvent('j'), keyEvent('j'), keyEvent('k')}, 1},
+		{"up at the top", []termbox.Event{key(termbox.KeyArrowUp), keyEvent('k')}, 0},
+		{"page down", []termbox.Event{key(termbox.KeyPgdn)}, 3},
+		{"page down at the bottom", []termbox.Event{key(termbox.KeyPgdn), key(termbox.KeyPgdn), key(termbox.KeyPgdn)}, 6},
+		{"page up", []termbox.Event{key(termbox.KeyEnd), key(termbox.KeyPgup)}, 3},
+		{"end and home", []termbox.Event{key(termbox.KeyEnd), key(termbox.KeyHome)}, 0},
+		{"end", []termbox.Event{key(termbox.KeyEnd), key(termbox.KeyArrowDown)}, 6},
+		{"wheel", []termbox.Event{{Type: termbox.EventMouse, Key: termbox.MouseWheelDown}}, 3},
+	}
+	for _, test := range tests {
+		l := newTestList("a", "b", "c", "d", "e", "f", "g")
+		for _, ev := range test.events {
+			l.HandleEvent(ev)
+		}
+		if got := l.Cursor(); got != test.want {
+			t.Errorf("%s: cursor = %d, want %d", test.name, got, test.want)
+		}
+	}
+}
+
+func TestListTypeToJump(t *testing.T) {
+	l := newTestList("apple", "banana", "blueberry", "cherry", "Bean")
+	tests := []struct {
+		ch   rune
+		want int
+	}{
+		{'b', 1},
+		// typing on continues the search, including the item under the cursor
+		{'l', 2},
+		{'x', 2},
+	}
+	for _, test := range tests {
+		l.HandleEvent(keyEvent(test.ch))
+		if got := l.Cursor(); got != test.want {
+			t.Errorf("after typing %q, cursor = %d, want %d", test.ch, got, test.want)
+		}
+	}
+	// after a pause a search starts again, after the cursor and ignoring case
+	l.lastJump = l.lastJump.Add(-2 * jumpTimeout)
+	l.HandleEvent(keyEvent('b'))
+	if got := l.Cursor(); got != 4 {
+		t.Errorf("new search moved the cursor to %d, want 4", got)
+	}
+	l.lastJump = l.lastJump.Add(-2 * jumpTimeout)
+	l.HandleEvent(keyEvent('b'))
+	if got := l.Cursor(); got != 1 {
+		t.Errorf("search didn't wrap around, cursor = %d", got)
+	}
+}
+
+func TestListSelect(t *testing.T) {
+	l := newTestList("a", "b", "c")
+	var selected [][]ListItem
+	l.OnSelect(func(items []ListItem) { selected = append(selected, items) })
+	selections := l.Selections()
+	l.HandleEvent(keyEvent('j'))
+	l.HandleEvent(termbox.Event{Type: termbox.EventKey, Key: termbox.KeyEnter})
+	if len(selected) != 1 || len(selected[0]) != 1 || selected[0][0].Value != 1 {
+		t.Fatalf("Enter selected %v", selected)
+	}
+	if items := <-selections; len(items) != 1 || items[0].Value != 1 {
+		t.Errorf("channel got %v", items)
+	}
+	// the first click moves the cursor, a second one on it selects
+	click := termbox.Event{Type: termbox.EventMouse, Key: termbox.MouseLeft, MouseY: 2}
+	l.HandleEvent(click)
+	if l.Cursor() != 2 || len(selected) != 1 {
+		t.Fatalf("click moved the cursor to %d and selected %v", l.Cursor(), selected)
+	}
+	l.HandleEvent(click)
+	if len(selected) != 2 || selected[1][0].Value != 2 {
+		t.Errorf("second click selected %v", selected)
+	}
+}
+
+func TestListMultiSelect(t *testing.T) {
+	l := newTestList("a", "b", "c", "d")
+	l.SetMultiSelect(true)
+	var selected []ListItem
+	l.OnSelect(func(items []ListItem) { selected = items })
+	space := termbox.Event{Type: termbox.EventKey, Key: termbox.KeySpace}
+	enter := termbox.Event{Type: termbox.EventKey, Key: termbox.KeyEnter}
+	// nothing toggled, nothing selected
+	l.HandleEvent(enter)
+	if selected != nil {
+		t.Fatalf("selected %v with nothing toggled", selected)
+	}
+	l.HandleEvent(space)
+	l.HandleEvent(keyEvent('j'))
+	l.HandleEvent(keyEvent('j'))
+	l.HandleEvent(space)
+	l.HandleEvent(keyEvent('j'))
+	l.HandleEvent(space)
+	// toggling again unticks the item
+	l.HandleEvent(space)
+	// clicking the item under the cursor toggles it too
+	l.HandleEvent(termbox.Event{Type: termbox.EventMouse, Key: termbox.MouseLeft, MouseY: 1})
+	l.HandleEvent(termbox.Event{Type: termbox.EventMouse, Key: termbox.MouseLeft, MouseY: 1})
+	l.HandleEvent(enter)
+	var values []interface{}
+	for _, item := range selected {
+		values = append(values, item.Value)
+	}
+	if !reflect.DeepEqual(values, []interface{}{0, 1, 2}) {
+		t.Errorf("selected values %v, want [0 1 2]", values)
+	}
+}
@@ -0,0 +1,177 @@
+package gopanes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Markup is a compact way to write styled text. Tags in square brackets
+// change the style of the text after them:
+//
+//	[fg]  [fg:bg]  [fg:bg:attrs]  [::attrs]
+//
+// Colors are names ("red", "lightblue", "default"), xterm 256 palette indices
+// ("208") or hex RGB values ("#ff8700"). Attributes are any of the letters
+// b (bold), u (underline), r (reverse) and l (blink). An empty field leaves
+// that part of the style alone, and "-" resets it, so "[-]" goes back to the
+// default foreground and "[::-]" drops all attributes. "[[" is a literal '['.
+//
+// For example: "[red]ERROR[-] disk [::b]full[::-]"
+
+// MarkupError describes a malformed markup string
+type MarkupError struct {
+	Offset int // byte offset of the offending tag
+	Msg    string
+}
+
+func (e *MarkupError) Error() string {
+	return fmt.Sprintf("markup: %s at offset %d", e.Msg, e.Offset)
+}
+
+var colorNames = map[string]TermColor{
+	"default":      ColorDefault,
+	"black":        ColorBlack,
+	"red":          ColorRed,
+	"green":        ColorGreen,
+	"yellow":       ColorYellow,
+	"blue":         ColorBlue,
+	"magenta":      ColorMagenta,
+	"cyan":         ColorCyan,
+	"white":        ColorWhite,
+	"darkgray":     ColorDarkGray,
+	"lightred":     ColorLightRed,
+	"lightgreen":   ColorLightGreen,
+	"lightyellow":  ColorLightYellow,
+	"lightblue":    ColorLightBlue,
+	"lightmagenta": ColorLightMagenta,
+	"lightcyan":    ColorLightCyan,
+	"brightwhite":  ColorBrightWhite,
+}
+
+// ParseColor parses a color name, 256 palette index or "#rrggbb" value
+func ParseColor(name string) (TermColor, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if color, ok := colorNames[name]; ok {
+		return color, nil
+	}
+	if strings.HasPrefix(name, "#") {
+		rgb, err := strconv.ParseUint(name[1:], 16, 32)
+		if err != nil || len(name) != 7 {
+			return ColorDefault, fmt.Errorf("invalid RGB color %q", name)
+		}
+		return ColorRGB(uint8(rgb>>16), uint8(rgb>>8), uint8(rgb)), nil
+	}
+	if idx, err := strconv.ParseUint(name, 10, 8); err == nil {
+		return Color256(uint8(idx)), nil
+	}
+	return ColorDefault, fmt.Errorf("unknown color %q", name)
+}
+
+// applyTag returns the style after the given tag (without brackets)
+func applyTag(style Style, tag string) (Style, error) {
+	fields := strings.Split(tag, ":")
+	if len(fields) > 3 {
+		return style, fmt.Errorf("too many fields in tag [%s]", tag)
+	}
+	for i, field := range fields {
+		if field == "" {
+			continue
+		}
+		switch i {
+		case 0, 1:
+			color := ColorDefault
+			if field != "-" {
+				var err error
+				if color, err = ParseColor(field); err != nil {
+					return style, err
+				}
+			}
+			if i == 0 {
+				style = style.Fg(color)
+			} else {
+				style = style.Bg(color)
+			}
+		case 2:
+			if field == "-" {
+				style.attrs = 0
+				continue
+			}
+			for _, attr := range field {
+				switch attr {
+				case 'b':
+					style = style.Bold()
+				case 'u':
+					style = style.Underline()
+				case 'r':
+					style = style.Reverse()
+				case 'l':
+					style = style.Blink()
+				default:
+					return style, fmt.Errorf("unknown attribute %q", attr)
+				}
+			}
+		}
+	}
+	return style, nil
+}
+
+// ParseMarkup turns a markup string into ColorStrs for AddLine, ChangePrompt
+// and friends
+func ParseMarkup(markup string) ([]ColorStr, error) {
+	var colorStrs []ColorStr
+	var text strings.Builder
+	style := NewStyle()
+	flush := func() {
+		if text.Len() > 0 {
+			colorStrs = append(colorStrs, style.Str(text.String()))
+			text.Reset()
+		}
+	}
+	for i := 0; i < len(markup); i++ {
+		if markup[i] != '[' {
+			text.WriteByte(markup[i])
+			continue
+		}
+		// escaped bracket
+		if i+1 < len(markup) && markup[i+1] == '[' {
+			text.WriteByte('[')
+			i++
+			continue
+		}
+		end := strings.IndexByte(markup[i:], ']')
+		if end < 0 {
+			return nil, &MarkupError{Offset: i, Msg: "unterminated tag"}
+		}
+		tag := markup[i+1 : i+end]
+		if tag == "" {
+			return nil, &MarkupError{Offset: i, Msg: "empty tag"}
+		}
+		newStyle, err := applyTag(style, tag)
+		if err != nil {
+			return nil, &MarkupError{Offset: i, Msg: err.Error()}
+		}
+		if newStyle != style {
+			flush()
+			style = newStyle
+		}
+		i += end
+	}
+	flush()
+	return colorStrs, nil
+}
+
+// MustParseMarkup is like ParseMarkup but panics on malformed markup. It's
+// meant for markup that is a constant in the program.
+func MustParseMarkup(markup string) []ColorStr {
+	colorStrs, err := ParseMarkup(markup)
+	if err != nil {
+		panic(err)
+	}
+	return colorStrs
+}
+
+// EscapeMarkup escapes a string so ParseMarkup shows it literally
+func EscapeMarkup(str string) string {
+	return strings.Replace(str, "[", "[[", -1)
+}
@@ -0,0 +1,56 @@
+package gopanes
+
+import (
+	"testing"
+)
+
+func TestParseMarkup(t *testing.T) {
+	got, err := ParseMarkup("[red]ERROR[-] disk [::b]full[::-] [[ok]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ColorStr{
+		NewStyle().Fg(ColorRed).Str("ERROR"),
+		NewStyle().Str(" disk "),
+		NewStyle().Bold().Str("full"),
+		NewStyle().Str(" [ok]"),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("segment %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseMarkupColors(t *testing.T) {
+	got, err := ParseMarkup("[#ff8700:208:bu]x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := NewStyle().Fg(ColorRGB(0xff, 0x87, 0)).Bg(Color256(208)).Bold().Underline()
+	if len(got) != 1 || got[0].Style != want {
+		t.Errorf("got %+v, want style %+v", got, want)
+	}
+}
+
+func TestParseMarkupErrors(t *testing.T) {
+	for _, markup := range []string{"[red", "[]", "[nocolor]x", "[::z]x", "[a:b:c:d]", "[#12345]"} {
+		_, err := ParseMarkup(markup)
+		if _, ok := err.(*MarkupError); !ok {
+			t.Errorf("%q: expected a MarkupError, got %v", markup, err)
+		}
+	}
+}
+
+func TestEscapeMarkup(t *testing.T) {
+	got, err := ParseMarkup(EscapeMarkup("[red] stays [literal]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Str != "[red] stays [literal]" {
+		t.Errorf("got %+v", got)
+	}
+}
@@ -0,0 +1,99 @@
+package gopanes
+
+import (
+	"github.com/mattn/go-runewidth"
+	"github.com/nsf/termbox-go"
+)
+
+// overlay is a widget shown in a box over the panes, like the paste buffer
+// chooser
+type overlay struct {
+	widget Widget
+	title  string
+}
+
+// ShowOverlay shows a widget in a box in the middle of the window, sized to
+// its PreferredSize. While it's open it gets the key events and the mouse
+// events over it. Esc closes it.
+func (gu *GoPaneUi) ShowOverlay(title string, w Widget) {
+	if gu.overlay != nil {
+		gu.overlay.widget.Blur()
+	}
+	gu.overlay = &overlay{widget: w, title: title}
+	w.Focus()
+	gu.refreshOverlay()
+}
+
+// CloseOverlay closes the overlay, if there is one
+func (gu *GoPaneUi) CloseOverlay() {
+	if gu.overlay == nil {
+		return
+	}
+	gu.overlay.widget.Blur()
+	gu.overlay = nil
+	termbox.HideCursor()
+	gu.Refresh()
+}
+
+// overlayArea returns the area inside the overlay's box
+func (gu *GoPaneUi) overlayArea() (x, y, width, height int) {
+	areaX, areaY, areaWidth, areaHeight := gu.paneArea()
+	width, height = gu.overlay.widget.PreferredSize()
+	if titleWidth := runewidth.StringWidth(gu.overlay.title) + 2; width < titleWidth {
+		width = titleWidth
+	}
+	// leave room for the box, and a little of the panes around it
+	if width <= 0 || width > areaWidth-4 {
+		width = areaWidth - 4
+	}
+	if height <= 0 || height > areaHeight-4 {
+		height = areaHeight - 4
+	}
+	x = areaX + (areaWidth-width)/2
+	y = areaY + (areaHeight-height)/2
+	return x, y, width, height
+}
+
+// the caller must hold termboxMutex
+func (gu *GoPaneUi) drawOverlay() {
+	if gu.overlay == nil {
+		return
+	}
+	x, y, width, height := gu.overlayArea()
+	theme := gu.currentTheme()
+	box := NewCanvas(x-1, y-1, width+2, height+2)
+	box.Box(0, 0, width+2, height+2, theme.FocusedDividerStyle)
+	if gu.overlay.title != "" {
+		box.Print(1, 0, " "+gu.overlay.title+" ", theme.FocusedTitleStyle)
+	}
+	gu.overlay.widget.Draw(NewCanvas(x, y, width, height))
+}
+
+// handleOverlayEvent passes an event to the overlay, closing it on Esc
+func (gu *GoPaneUi) handleOverlayEvent(ev termbox.Event) {
+	x, y, width, height := gu.overlayArea()
+	switch {
+	case ev.Type == termbox.EventKey && ev.Key == termbox.KeyEsc:
+		gu.CloseOverlay()
+		return
+	case ev.Type == termbox.EventMouse:
+		if ev.MouseX < x || ev.MouseX >= x+width || ev.MouseY < y || ev.MouseY >= y+height {
+			return
+		}
+		ev.MouseX -= x
+		ev.MouseY -= y
+	}
+	gu.overlay.widget.HandleEvent(ev)
+	// the widget may have closed the overlay
+	if gu.overlay != nil {
+		gu.refreshOverlay()
+	}
+}
+
+// refreshOverlay redraws just the overlay
+func (gu *GoPaneUi) refreshOverlay() {
+	termboxMutex.Lock()
+	defer termboxMutex.Unlock()
+	gu.drawOverlay()
+	drawScreen.Flush()
+}
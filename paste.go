@@ -0,0 +1,133 @@
+package gopanes
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// PasteBuffer is a piece of copied text. The UI keeps a stack of them that
+// can be pasted into any EditBox.
+type PasteBuffer struct {
+	Name string `json:"name"`
+	Text string `json:"text"`
+}
+
+// Copy pushes text onto the paste buffer stack, named like buffer0, buffer1
+// and so on, and puts it on the system clipboard
+func (gu *GoPaneUi) Copy(text string) {
+	gu.SetBuffer(gu.nextBufferName(), text)
+	CopyToClipboard(text)
+}
+
+// nextBufferName returns the next bufferN name that isn't taken, since
+// loaded buffers can have those names too
+func (gu *GoPaneUi) nextBufferName() string {
+	for {
+		name := fmt.Sprintf("buffer%d", gu.nextBuffer)
+		gu.nextBuffer++
+		if _, taken := gu.Buffer(name); !taken {
+			return name
+		}
+	}
+}
+
+// SetBuffer puts text on top of the paste buffer stack under the given name,
+// replacing any buffer already called that
+func (gu *GoPaneUi) SetBuffer(name, text string) {
+	gu.DeleteBuffer(name)
+	gu.pasteBuffers = append([]PasteBuffer{{Name: name, Text: text}}, gu.pasteBuffers...)
+}
+
+// Buffer returns the text of the named paste buffer, or of the top one if the
+// name is empty
+func (gu *GoPaneUi) Buffer(name string) (string, bool) {
+	for _, buffer := range gu.pasteBuffers {
+		if name == "" || buffer.Name == name {
+			return buffer.Text, true
+		}
+	}
+	return "", false
+}
+
+// DeleteBuffer removes the named paste buffer. It returns false if there was
+// no buffer called that.
+func (gu *GoPaneUi) DeleteBuffer(name string) bool {
+	for idx, buffer := range gu.pasteBuffers {
+		if buffer.Name == name {
+			gu.pasteBuffers = append(gu.pasteBuffers[:idx], gu.pasteBuffers[idx+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// PasteBuffers returns the paste buffers, most recent first
+func (gu *GoPaneUi) PasteBuffers() []PasteBuffer {
+	return append([]PasteBuffer(nil), gu.pasteBuffers...)
+}
+
+// Paste inserts the named paste buffer, or the top one if the name is empty,
+// into the pane's EditBox. It returns false if the pane isn't editable or
+// there is no such buffer.
+func (gu *GoPaneUi) Paste(gp *GoPane, name string) bool {
+	eb := gp.editBox()
+	if eb == nil {
+		return false
+	}
+	text, ok := gu.Buffer(name)
+	if !ok {
+		return false
+	}
+	eb.InsertText(text)
+	gp.Refresh()
+	return true
+}
+
+// ChooseBuffer lists the paste buffers in an overlay. Choosing one pastes it
+// into the pane's EditBox.
+func (gu *GoPaneUi) ChooseBuffer(gp *GoPane) {
+	if len(gu.pasteBuffers) == 0 || gp.editBox() == nil {
+		return
+	}
+	list := NewList()
+	for _, buffer := range gu.pasteBuffers {
+		// show the start of the text on one line
+		preview := strings.Replace(buffer.Text, "\n", " ", -1)
+		preview = truncate(preview, 40)
+		list.AddItem([]ColorStr{NewStyle().Bold().Str(buffer.Name + ": "), NewStyle().Str(preview)}, buffer.Name)
+	}
+	list.OnSelect(func(items []ListItem) {
+		gu.CloseOverlay()
+		gu.Paste(gp, items[0].Value.(string))
+	})
+	gu.ShowOverlay("Paste buffers", list)
+}
+
+// SaveBuffers writes the paste buffers to a JSON file
+func (gu *GoPaneUi) SaveBuffers(path string) error {
+	data, err := json.MarshalIndent(gu.pasteBuffers, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// LoadBuffers reads paste buffers saved with SaveBuffers, putting them on top
+// of the stack in the order they were saved. Buffers with the same names as
+// loaded ones are replaced.
+func (gu *GoPaneUi) LoadBuffers(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var buffers []PasteBuffer
+	if err := json.Unmarshal(data, &buffers); err != nil {
+		return err
+	}
+	for idx := len(buffers) - 1; idx >= 0; idx-- {
+		gu.SetBuffer(buffers[idx].Name, buffers[idx].Text)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package gopanes
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPasteBuffers(t *testing.T) {
+	gu := &GoPaneUi{}
+	gu.SetBuffer("a", "first")
+	gu.SetBuffer("b", "second")
+	gu.SetBuffer("a", "replaced")
+	want := []PasteBuffer{{"a", "replaced"}, {"b", "second"}}
+	if got := gu.PasteBuffers(); !reflect.DeepEqual(got, want) {
+		t.Errorf("PasteBuffers() = %v, want %v", got, want)
+	}
+	if text, ok := gu.Buffer(""); !ok || text != "replaced" {
+		t.Errorf(`Buffer("") = %q, %v`, text, ok)
+	}
+
+	path := filepath.Join(t.TempDir(), "buffers.json")
+	if err := gu.SaveBuffers(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded := &GoPaneUi{}
+	loaded.SetBuffer("b", "old")
+	loaded.SetBuffer("c", "kept")
+	if err := loaded.LoadBuffers(path); err != nil {
+		t.Fatal(err)
+	}
+	want = []PasteBuffer{{"a", "replaced"}, {"b", "second"}, {"c", "kept"}}
+	if got := loaded.PasteBuffers(); !reflect.DeepEqual(got, want) {
+		t.Errorf("after LoadBuffers, PasteBuffers() = %v, want %v", got, want)
+	}
+}
+
+func TestCopiedBufferNames(t *testing.T) {
+	gu := &GoPaneUi{}
+	gu.SetBuffer("buffer0", "loaded")
+	gu.SetBuffer("buffer2", "loaded")
+	var names []string
+	for i := 0; i < 3; i++ {
+		name := gu.nextBufferName()
+		gu.SetBuffer(name, "copied")
+		names = append(names, name)
+	}
+	if want := []string{"buffer1", "buffer3", "buffer4"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names = %q, want %q", names, want)
+	}
+	if text, _ := gu.Buffer("buffer0"); text != "loaded" {
+		t.Errorf("the loaded buffer0 was overwritten with %q", text)
+	}
+}
@@ -0,0 +1,279 @@
+package gopanes
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// RestartPolicy says when a supervised process is started again after it
+// exits
+type RestartPolicy int
+
+const (
+	RestartNever RestartPolicy = iota
+	RestartOnFailure
+	RestartAlways
+)
+
+// ProcessState is what a supervised process is doing
+type ProcessState int
+
+const (
+	ProcessRunning ProcessState = iota
+	ProcessExited               // exited successfully
+	ProcessCrashed              // exited with an error or couldn't be started
+	ProcessStopped              // stopped by Stop
+)
+
+func (s ProcessState) String() string {
+	switch s {
+	case ProcessRunning:
+		return "running"
+	case ProcessExited:
+		return "exited"
+	case ProcessCrashed:
+		return "crashed"
+	}
+	return "stopped"
+}
+
+type ProcessOptions struct {
+	Restart RestartPolicy
+	// how long to wait before restarting, doubling after each restart up to
+	// MaxBackoff. They default to a second and half a minute. The wait goes
+	// back to Backoff once the process stays up for MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// the requests the prefix commands send a supervisor
+type processRequest int
+
+const (
+	processNone processRequest = iota
+	processRestart
+	processStop
+)
+
+// Process is a command run in a pane and restarted by its restart policy, see
+// GoPane.Supervise
+type Process struct {
+	lock     sync.Mutex
+	name     string
+	command  func() *exec.Cmd
+	opts     ProcessOptions
+	state    ProcessState
+	restarts int
+	requests chan processRequest
+	done     <-chan struct{} // the pane's, the supervisor stops when it's closed
+
+	addLine   func(line []ColorStr)
+	setStatus func(status string)
+}
+
+// Supervise runs a command in the pane, showing its output as RunCommand does
+// and its state in the pane's title, restarting it by the restart policy.
+// command is called for every start, since an exec.Cmd can only be run once.
+// The prefix key followed by r restarts the focused pane's process, and
+// followed by x stops it. The process is stopped for good when the pane is
+// closed.
+func (gp *GoPane) Supervise(name string, command func() *exec.Cmd, opts ProcessOptions) *Process {
+	gp.SetTitle(name)
+	p := newProcess(name, command, opts, gp.Done(), func(line []ColorStr) {
+		gp.AddLine(line)
+		gp.Refresh()
+	}, func(status string) {
+		gp.SetTitleStatus(status)
+		gp.Refresh()
+	})
+	gp.SetProcess(p)
+	go p.supervise()
+	return p
+}
+
+func newProcess(name string, command func() *exec.Cmd, opts ProcessOptions, done <-chan struct{},
+	addLine func(line []ColorStr), setStatus func(status string)) *Process {
+	if opts.Backoff <= 0 {
+		opts.Backoff = time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 30 * time.Second
+	}
+	return &Process{
+		name:      name,
+		command:   command,
+		opts:      opts,
+		requests:  make(chan processRequest, 1),
+		done:      done,
+		addLine:   addLine,
+		setStatus: setStatus,
+	}
+}
+
+// SetProcess records the process the pane is running, for the prefix commands
+func (gp *GoPane) SetProcess(p *Process) {
+	if gp.isSplit() {
+		gp.First.SetProcess(p)
+		return
+	}
+	gp.process = p
+}
+
+// Process returns the supervised process the pane is running, if any
+func (gp *GoPane) Process() *Process {
+	if gp.isSplit() {
+		return nil
+	}
+	return gp.process
+}
+
+// State returns what the process is doing
+func (p *Process) State() ProcessState {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.state
+}
+
+// Restarts returns how many times the process has been started again
+func (p *Process) Restarts() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.restarts
+}
+
+// Restart kills the process if it's running and starts it again right away
+func (p *Process) Restart() { p.request(processRestart) }
+
+// Stop kills the process if it's running and keeps it from being restarted
+// until Restart is called
+func (p *Process) Stop() { p.request(processStop) }
+
+func (p *Process) request(req processRequest) {
+	// a request that's still waiting is replaced
+	select {
+	case <-p.requests:
+	default:
+	}
+	select {
+	case p.requests <- req:
+	default:
+	}
+}
+
+func (p *Process) setState(state ProcessState, status string) {
+	p.lock.Lock()
+	p.state = state
+	p.lock.Unlock()
+	p.setStatus(status)
+}
+
+// supervise runs the process until the pane is closed
+func (p *Process) supervise() {
+	backoff := p.opts.Backoff
+	for {
+		started := time.Now()
+		state, status, req, closed := p.runOnce()
+		if closed {
+			return
+		}
+		if time.Since(started) >= p.opts.MaxBackoff {
+			backoff = p.opts.Backoff
+		}
+		if req == processStop {
+			p.setState(ProcessStopped, "stopped")
+			if !p.waitForRestart() {
+				return
+			}
+			backoff = p.opts.Backoff
+		} else if req != processRestart {
+			// it exited by itself
+			restart := p.opts.Restart == RestartAlways ||
+				(p.opts.Restart == RestartOnFailure && state == ProcessCrashed)
+			if !restart {
+				p.setState(state, status)
+				if !p.waitForRestart() {
+					return
+				}
+				backoff = p.opts.Backoff
+			} else {
+				p.setState(state, fmt.Sprintf("%s, restarting in %v", status, backoff))
+				if !p.waitForBackoff(backoff) {
+					return
+				}
+				backoff *= 2
+				if backoff > p.opts.MaxBackoff {
+					backoff = p.opts.MaxBackoff
+				}
+			}
+		}
+		p.lock.Lock()
+		p.restarts++
+		p.lock.Unlock()
+	}
+}
+
+// runOnce runs the process until it exits, returning the state and status it
+// ended in, the request that ended it if any, or whether the pane was closed
+func (p *Process) runOnce() (state ProcessState, status string, req processRequest, closed bool) {
+	run, err := startCommand(context.Background(), nil, p.command(), p.addLine)
+	if err != nil {
+		p.addLine([]ColorStr{Color.Red(fmt.Sprintf("--- %s failed to start: %v ---", p.name, err))})
+		return ProcessCrashed, "crashed", processNone, false
+	}
+	p.setState(ProcessRunning, "running")
+	select {
+	case <-run.Done():
+	case req = <-p.requests:
+		run.Stop()
+		return ProcessStopped, "", req, false
+	case <-p.done:
+		run.Stop()
+		return ProcessStopped, "", processNone, true
+	}
+	err = run.Wait()
+	if err == nil {
+		return ProcessExited, "exited", processNone, false
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() >= 0 {
+		return ProcessCrashed, fmt.Sprintf("crashed (status %d)", exitErr.ExitCode()), processNone, false
+	}
+	return ProcessCrashed, "crashed", processNone, false
+}
+
+// waitForRestart waits for a restart request, returning false if the pane is
+// closed first
+func (p *Process) waitForRestart() bool {
+	for {
+		select {
+		case req := <-p.requests:
+			if req == processRestart {
+				return true
+			}
+		case <-p.done:
+			return false
+		}
+	}
+}
+
+// waitForBackoff waits before restarting, returning false if the pane is
+// closed or the process is stopped first. A restart request ends the wait
+// early.
+func (p *Process) waitForBackoff(backoff time.Duration) bool {
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case req := <-p.requests:
+		if req == processRestart {
+			return true
+		}
+		p.setState(ProcessStopped, "stopped")
+		return p.waitForRestart()
+	case <-p.done:
+		return false
+	}
+}
@@ -0,0 +1,121 @@
+package gopanes
+
+import (
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testProcess supervises a process, recording the statuses it reports
+type testProcess struct {
+	*Process
+	lock     sync.Mutex
+	statuses []string
+}
+
+func startTestProcess(command func() *exec.Cmd, opts ProcessOptions, done <-chan struct{}) *testProcess {
+	tp := &testProcess{}
+	tp.Process = newProcess("test", command, opts, done, func([]ColorStr) {}, func(status string) {
+		tp.lock.Lock()
+		tp.statuses = append(tp.statuses, status)
+		tp.lock.Unlock()
+	})
+	go tp.supervise()
+	return tp
+}
+
+func (tp *testProcess) lastStatus() string {
+	tp.lock.Lock()
+	defer tp.lock.Unlock()
+	if len(tp.statuses) == 0 {
+		return ""
+	}
+	return tp.statuses[len(tp.statuses)-1]
+}
+
+// waitFor waits until the process reports a status
+func (tp *testProcess) waitFor(t *testing.T, status string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for tp.lastStatus() != status {
+		if time.Now().After(deadline) {
+			t.Fatalf("status is %q, want %q", tp.lastStatus(), status)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestProcessRestartsOnFailure(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+	starts := 0
+	tp := startTestProcess(func() *exec.Cmd {
+		starts++
+		if starts < 3 {
+			return exec.Command("sh", "-c", "exit 2")
+		}
+		return exec.Command("true")
+	}, ProcessOptions{Restart: RestartOnFailure, Backoff: time.Millisecond, MaxBackoff: time.Hour}, done)
+	tp.waitFor(t, "exited")
+	if tp.State() != ProcessExited || tp.Restarts() != 2 {
+		t.Errorf("state %v after %d restarts, want exited after 2", tp.State(), tp.Restarts())
+	}
+	tp.lock.Lock()
+	defer tp.lock.Unlock()
+	want := []string{
+		"running", "crashed (status 2), restarting in 1ms",
+		"running", "crashed (status 2), restarting in 2ms",
+		"running", "exited",
+	}
+	for idx := range want {
+		if idx >= len(tp.statuses) || tp.statuses[idx] != want[idx] {
+			t.Fatalf("statuses = %q, want %q", tp.statuses, want)
+		}
+	}
+}
+
+func TestProcessStopAndRestart(t *testing.T) {
+	done := make(chan struct{})
+	tp := startTestProcess(func() *exec.Cmd {
+		return exec.Command("sleep", "10")
+	}, ProcessOptions{Restart: RestartAlways}, done)
+	tp.waitFor(t, "running")
+	tp.Stop()
+	tp.waitFor(t, "stopped")
+	if tp.State() != ProcessStopped {
+		t.Errorf("state = %v, want stopped", tp.State())
+	}
+	tp.Restart()
+	tp.waitFor(t, "running")
+	tp.Restart()
+	deadline := time.Now().Add(5 * time.Second)
+	for tp.Restarts() != 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if tp.Restarts() != 2 {
+		t.Errorf("Restarts() = %d, want 2", tp.Restarts())
+	}
+	close(done)
+}
+
+func TestProcessWithChildrenRestarts(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+	// like a service started through a shell, with a child holding its output
+	tp := startTestProcess(func() *exec.Cmd {
+		return exec.Command("sh", "-c", "sleep 30 | cat")
+	}, ProcessOptions{Restart: RestartNever}, done)
+	tp.waitFor(t, "running")
+	tp.Restart()
+	deadline := time.Now().Add(5 * time.Second)
+	for tp.Restarts() != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if tp.Restarts() != 1 {
+		t.Fatal("the process wasn't restarted")
+	}
+	tp.waitFor(t, "running")
+	tp.Stop()
+	tp.waitFor(t, "stopped")
+}
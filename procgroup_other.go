@@ -0,0 +1,16 @@
+//go:build !unix
+
+package gopanes
+
+import (
+	"os/exec"
+)
+
+// process groups are a Unix thing, so elsewhere only the command itself is
+// killed
+
+func startProcessGroup(cmd *exec.Cmd) {}
+
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
@@ -0,0 +1,23 @@
+//go:build unix
+
+package gopanes
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// startProcessGroup makes a command start a process group of its own, so the
+// processes it starts can be killed along with it
+func startProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// killProcessGroup kills a command started with startProcessGroup and every
+// process still in its group
+func killProcessGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
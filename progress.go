@@ -0,0 +1,458 @@
+package gopanes
+
+import (
+	"fmt"
+	"github.com/mattn/go-runewidth"
+	"github.com/nsf/termbox-go"
+	"sync"
+	"time"
+)
+
+// indicator is what the progress and chart widgets have in common: they can
+// host a whole pane or be a line in a pane's content, and either way redraw
+// the pane whenever they change
+type indicator struct {
+	lock      sync.Mutex
+	pane      *GoPane    // the pane showing the indicator
+	line      LineHandle // the line showing it, if it's shown as a line
+	lineWidth int
+	render    func(width int) []ColorStr
+}
+
+// AddLineTo adds a line showing the indicator, rendered at the given width,
+// to the pane's content and keeps it up to date
+func (ind *indicator) AddLineTo(gp *GoPane, width int) LineHandle {
+	ind.lock.Lock()
+	ind.pane = gp
+	ind.lineWidth = width
+	ind.lock.Unlock()
+	line := gp.AddLine(ind.render(width))
+	ind.lock.Lock()
+	ind.line = line
+	ind.lock.Unlock()
+	gp.Refresh()
+	return line
+}
+
+// changed updates the indicator's line and redraws its pane. The caller must
+// not hold lock.
+func (ind *indicator) changed() {
+	ind.lock.Lock()
+	pane, line, width := ind.pane, ind.line, ind.lineWidth
+	ind.lock.Unlock()
+	if line.Valid() {
+		line.Set(ind.render(width))
+	}
+	if pane != nil {
+		pane.Refresh()
+	}
+}
+
+// show hosts the widget in the pane
+func (ind *indicator) show(gp *GoPane, w Widget) {
+	ind.lock.Lock()
+	ind.pane = gp
+	ind.lock.Unlock()
+	gp.SetWidget(w)
+	gp.Refresh()
+}
+
+func (ind *indicator) HandleEvent(ev termbox.Event) {}
+func (ind *indicator) Focus()                       {}
+func (ind *indicator) Blur()                        {}
+
+// percentage formats a fraction between 0 and 1 as a percentage
+func percentage(fraction float64) string {
+	return fmt.Sprintf("%3.0f%%", fraction*100)
+}
+
+// ProgressBar shows how far along some work is, as a label, a bar, the
+// percentage done and an estimate of the time left
+type ProgressBar struct {
+	indicator
+	label   string
+	current float64
+	total   float64
+	started time.Time // when the work started, zero until then
+
+	LabelStyle Style
+	FillStyle  Style
+	EmptyStyle Style
+}
+
+func NewProgressBar(label string, total float64) *ProgressBar {
+	pb := &ProgressBar{
+		label:      label,
+		total:      total,
+		FillStyle:  NewStyle().Fg(ColorGreen),
+		EmptyStyle: NewStyle().Fg(ColorDarkGray),
+	}
+	pb.render = pb.Line
+	return pb
+}
+
+// ShowIn hosts the progress bar in the pane
+func (pb *ProgressBar) ShowIn(gp *GoPane) {
+	pb.show(gp, pb)
+}
+
+func (pb *ProgressBar) SetLabel(label string) {
+	pb.lock.Lock()
+	pb.label = label
+	pb.lock.Unlock()
+	pb.changed()
+}
+
+func (pb *ProgressBar) SetTotal(total float64) {
+	pb.lock.Lock()
+	pb.total = total
+	pb.lock.Unlock()
+	pb.changed()
+}
+
+// Start starts the clock the ETA is worked out with, which otherwise starts
+// at the first Set or Add. Call it when the work begins if that's a while
+// before it first reports progress.
+func (pb *ProgressBar) Start() {
+	pb.lock.Lock()
+	pb.start()
+	pb.lock.Unlock()
+}
+
+// the caller must hold lock
+func (pb *ProgressBar) start() {
+	if pb.started.IsZero() {
+		pb.started = time.Now()
+	}
+}
+
+// Set sets how much of the total is done
+func (pb *ProgressBar) Set(current float64) {
+	pb.lock.Lock()
+	pb.start()
+	pb.current = current
+	pb.lock.Unlock()
+	pb.changed()
+}
+
+// Add adds to how much of the total is done
+func (pb *ProgressBar) Add(delta float64) {
+	pb.lock.Lock()
+	pb.start()
+	pb.current += delta
+	pb.lock.Unlock()
+	pb.changed()
+}
+
+// the caller must hold lock
+func (pb *ProgressBar) fraction() float64 {
+	if pb.total <= 0 {
+		return 0
+	}
+	fraction := pb.current / pb.total
+	if fraction > 1 {
+		return 1
+	}
+	if fraction < 0 {
+		return 0
+	}
+	return fraction
+}
+
+// Fraction returns how much of the work is done, from 0 to 1
+func (pb *ProgressBar) Fraction() float64 {
+	pb.lock.Lock()
+	defer pb.lock.Unlock()
+	return pb.fraction()
+}
+
+// ETA estimates the time left from the rate so far, or returns 0 if there's
+// nothing to go on or the work is done
+func (pb *ProgressBar) ETA() time.Duration {
+	pb.lock.Lock()
+	defer pb.lock.Unlock()
+	return pb.eta()
+}
+
+// the caller must hold lock
+func (pb *ProgressBar) eta() time.Duration {
+	fraction := pb.fraction()
+	if fraction <= 0 || fraction >= 1 || pb.started.IsZero() {
+		return 0
+	}
+	elapsed := time.Since(pb.started)
+	return time.Duration(float64(elapsed) * (1 - fraction) / fraction)
+}
+
+// Line renders the progress bar as a line of the given width
+func (pb *ProgressBar) Line(width int) []ColorStr {
+	pb.lock.Lock()
+	defer pb.lock.Unlock()
+	var line []ColorStr
+	if pb.label != "" {
+		line = append(line, pb.LabelStyle.Str(pb.label+" "))
+	}
+	fraction := pb.fraction()
+	suffix := " " + percentage(fraction)
+	if eta := pb.eta().Round(time.Second); eta > 0 {
+		suffix += " ETA " + eta.String()
+	}
+	barWidth := width - colorStrsWidth(line) - runewidth.StringWidth(suffix)
+	if barWidth > 0 {
+		filled := int(fraction * float64(barWidth))
+		line = append(line,
+			pb.FillStyle.Str(repeatRune(glyphs().barFull, filled)),
+			pb.EmptyStyle.Str(repeatRune(glyphs().barEmpty, barWidth-filled)))
+	}
+	return append(line, NewStyle().Str(suffix))
+}
+
+// PreferredSize is a single row
+func (pb *ProgressBar) PreferredSize() (width, height int) {
+	return 0, 1
+}
+
+func (pb *ProgressBar) Draw(c *Canvas) {
+	c.Clear(NewStyle())
+	c.PrintColorStrs(0, 0, pb.Line(c.Width()))
+}
+
+// Gauge shows a level, such as how full a disk is, as a bar with its label
+// and percentage in the middle
+type Gauge struct {
+	indicator
+	label string
+	value float64 // from 0 to 1
+
+	FillStyle  Style
+	EmptyStyle Style
+}
+
+func NewGauge(label string) *Gauge {
+	g := &Gauge{
+		label:      label,
+		FillStyle:  NewStyle().Fg(ColorBlack).Bg(ColorGreen),
+		EmptyStyle: NewStyle().Reverse(),
+	}
+	g.render = g.Line
+	return g
+}
+
+// ShowIn hosts the gauge in the pane
+func (g *Gauge) ShowIn(gp *GoPane) {
+	g.show(gp, g)
+}
+
+func (g *Gauge) SetLabel(label string) {
+	g.lock.Lock()
+	g.label = label
+	g.lock.Unlock()
+	g.changed()
+}
+
+// Set sets the level, from 0 to 1
+func (g *Gauge) Set(value float64) {
+	if value < 0 {
+		value = 0
+	}
+	if value > 1 {
+		value = 1
+	}
+	g.lock.Lock()
+	g.value = value
+	g.lock.Unlock()
+	g.changed()
+}
+
+func (g *Gauge) Value() float64 {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.value
+}
+
+// text is what's shown in the middle of the gauge. The caller must hold lock.
+func (g *Gauge) text() string {
+	if g.label == "" {
+		return percentage(g.value)
+	}
+	return g.label + " " + percentage(g.value)
+}
+
+// Line renders the gauge as a line of the given width
+func (g *Gauge) Line(width int) []ColorStr {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.row(width, g.text())
+}
+
+// row renders a row of the gauge with text centred in it. The caller must
+// hold lock.
+func (g *Gauge) row(width int, text string) []ColorStr {
+	text = truncate(text, width)
+	start := (width - runewidth.StringWidth(text)) / 2
+	filled := int(g.value * float64(width))
+	var line []ColorStr
+	col := 0
+	add := func(str string, w int) {
+		style := g.EmptyStyle
+		if col < filled {
+			style = g.FillStyle
+		}
+		line = append(line, style.Str(str))
+		col += w
+	}
+	for col < start {
+		add(" ", 1)
+	}
+	for _, ch := range text {
+		add(string(ch), runewidth.RuneWidth(ch))
+	}
+	for col < width {
+		add(" ", 1)
+	}
+	return line
+}
+
+// PreferredSize is a single row, though the gauge can be taller
+func (g *Gauge) PreferredSize() (width, height int) {
+	return 0, 1
+}
+
+func (g *Gauge) Draw(c *Canvas) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	middle := (c.Height() - 1) / 2
+	for y := 0; y < c.Height(); y++ {
+		text := ""
+		if y == middle {
+			text = g.text()
+		}
+		c.PrintColorStrs(0, y, g.row(c.Width(), text))
+	}
+}
+
+// Spinner is an animation showing that something is happening when there's
+// no telling how far along it is
+type Spinner struct {
+	indicator
+	label   string
+	frame   int
+	running bool
+	stop    chan struct{}
+
+	Style Style
+	// how often the spinner moves, defaults to a tenth of a second
+	Interval time.Duration
+}
+
+func NewSpinner(label string) *Spinner {
+	s := &Spinner{label: label, Style: NewStyle().Fg(ColorCyan)}
+	s.render = s.Line
+	return s
+}
+
+// ShowIn hosts the spinner in the pane
+func (s *Spinner) ShowIn(gp *GoPane) {
+	s.show(gp, s)
+}
+
+func (s *Spinner) SetLabel(label string) {
+	s.lock.Lock()
+	s.label = label
+	s.lock.Unlock()
+	s.changed()
+}
+
+// Start starts the animation, which also stops when the pane showing the
+// spinner is closed
+func (s *Spinner) Start() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.running {
+		return
+	}
+	interval := s.Interval
+	if interval <= 0 {
+		interval = 100 * time.Millisecond
+	}
+	s.running = true
+	s.stop = make(chan struct{})
+	go s.ticker(interval, s.stop)
+}
+
+// Stop stops the animation, leaving the spinner showing the label
+func (s *Spinner) Stop() {
+	s.lock.Lock()
+	if s.running {
+		close(s.stop)
+		s.running = false
+	}
+	s.lock.Unlock()
+	s.changed()
+}
+
+// ticker moves the spinner until it's stopped or the pane showing it is
+// closed
+func (s *Spinner) ticker(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		// the spinner may be put in a pane after it's started
+		s.lock.Lock()
+		pane := s.pane
+		s.lock.Unlock()
+		var done <-chan struct{}
+		if pane != nil {
+			done = pane.Done()
+		}
+		select {
+		case <-ticker.C:
+			s.lock.Lock()
+			s.frame++
+			s.lock.Unlock()
+			s.changed()
+		case <-done:
+			s.lock.Lock()
+			if s.stop == stop {
+				s.running = false
+			}
+			s.lock.Unlock()
+			return
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Line renders the spinner as a line, which is only as wide as it needs to be
+func (s *Spinner) Line(width int) []ColorStr {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	frames := []rune(glyphs().spinner)
+	frame := " "
+	if s.running {
+		frame = string(frames[s.frame%len(frames)])
+	}
+	return []ColorStr{s.Style.Str(frame), NewStyle().Str(" " + s.label)}
+}
+
+// PreferredSize is the width of the spinner and its label
+func (s *Spinner) PreferredSize() (width, height int) {
+	return colorStrsWidth(s.Line(0)), 1
+}
+
+func (s *Spinner) Draw(c *Canvas) {
+	c.Clear(NewStyle())
+	c.PrintColorStrs(0, 0, s.Line(c.Width()))
+}
+
+func repeatRune(ch rune, count int) string {
+	if count <= 0 {
+		return ""
+	}
+	runes := make([]rune, count)
+	for i := range runes {
+		runes[i] = ch
+	}
+	return string(runes)
+}
@@ -0,0 +1,83 @@
+package gopanes
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProgressBarFraction(t *testing.T) {
+	pb := NewProgressBar("", 10)
+	for _, test := range []struct {
+		current, want float64
+	}{{5, 0.5}, {15, 1}, {-5, 0}} {
+		pb.Set(test.current)
+		if got := pb.Fraction(); got != test.want {
+			t.Errorf("with %v of 10 done, Fraction() = %v, want %v", test.current, got, test.want)
+		}
+	}
+	pb.SetTotal(0)
+	if got := pb.Fraction(); got != 0 {
+		t.Errorf("with no total, Fraction() = %v, want 0", got)
+	}
+}
+
+func TestProgressBarETA(t *testing.T) {
+	pb := NewProgressBar("", 100)
+	if !pb.started.IsZero() {
+		t.Error("the clock started before the work did")
+	}
+	pb.Add(25)
+	if pb.started.IsZero() {
+		t.Fatal("the clock didn't start at the first Add")
+	}
+	// a quarter done in ten seconds leaves thirty to go
+	pb.lock.Lock()
+	pb.started = time.Now().Add(-10 * time.Second)
+	pb.lock.Unlock()
+	if eta := pb.ETA(); eta < 29*time.Second || eta > 31*time.Second {
+		t.Errorf("ETA() = %v, want about 30s", eta)
+	}
+	pb.Set(100)
+	if eta := pb.ETA(); eta != 0 {
+		t.Errorf("when done, ETA() = %v, want 0", eta)
+	}
+}
+
+func TestProgressBarLine(t *testing.T) {
+	pb := NewProgressBar("dl", 10)
+	pb.Set(5)
+	g := glyphs()
+	want := "dl " + repeatRune(g.barFull, 6) + repeatRune(g.barEmpty, 6) + "  50%"
+	if got := lineText(pb.Line(20)); got != want {
+		t.Errorf("Line(20) = %q, want %q", got, want)
+	}
+	// too narrow for the bar
+	if got := lineText(pb.Line(4)); got != "dl   50%" {
+		t.Errorf("Line(4) = %q", got)
+	}
+}
+
+func TestSpinnerStopsWithPane(t *testing.T) {
+	ui, _ := newTestUi(t, 20, 2)
+	s := NewSpinner("working")
+	s.Interval = time.Millisecond
+	s.Start()
+	// shown after starting, the spinner still stops when the pane closes
+	s.ShowIn(ui.Root)
+	ui.Root.Close()
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		s.lock.Lock()
+		running := s.running
+		s.lock.Unlock()
+		if !running {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the spinner kept running in a closed pane")
+		}
+	}
+	// it can still be stopped and started again
+	s.Stop()
+	s.Start()
+	s.Stop()
+}
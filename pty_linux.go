@@ -0,0 +1,76 @@
+package gopanes
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"unsafe"
+)
+
+// openPty opens a new pseudo-terminal, returning its master end, which the
+// terminal side reads and writes, and its slave end, which the program runs on
+func openPty() (master, slave *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	var number uint32
+	if err := ioctl(master, syscall.TIOCGPTN, unsafe.Pointer(&number)); err != nil {
+		master.Close()
+		return nil, nil, fmt.Errorf("getting the pty number: %v", err)
+	}
+	var unlock int32
+	if err := ioctl(master, syscall.TIOCSPTLCK, unsafe.Pointer(&unlock)); err != nil {
+		master.Close()
+		return nil, nil, fmt.Errorf("unlocking the pty: %v", err)
+	}
+	slave, err = os.OpenFile(fmt.Sprintf("/dev/pts/%d", number), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	return master, slave, nil
+}
+
+// setPtySize tells the program on a pseudo-terminal how big its screen is
+func setPtySize(master *os.File, width, height int) error {
+	size := struct {
+		rows, cols, xPixels, yPixels uint16
+	}{rows: uint16(height), cols: uint16(width)}
+	return ioctl(master, syscall.TIOCSWINSZ, unsafe.Pointer(&size))
+}
+
+// startOnPty starts a command in a new session with the slave end of a
+// pseudo-terminal as its controlling terminal
+func startOnPty(cmd *exec.Cmd, slave *os.File) error {
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, slave
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	// the controlling terminal is the child's stdin
+	cmd.SysProcAttr.Setsid = true
+	cmd.SysProcAttr.Setctty = true
+	cmd.SysProcAttr.Ctty = 0
+	return cmd.Start()
+}
+
+// ioctl runs an ioctl on a file without taking it out of non-blocking mode,
+// which would stop Close from interrupting reads
+func ioctl(f *os.File, request uintptr, arg unsafe.Pointer) error {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	err = conn.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(arg))
+	})
+	if err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+
+package gopanes
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+var errNoPty = errors.New("terminal panes need Linux pseudo-terminals")
+
+func openPty() (master, slave *os.File, err error) {
+	return nil, nil, errNoPty
+}
+
+func setPtySize(master *os.File, width, height int) error {
+	return errNoPty
+}
+
+func startOnPty(cmd *exec.Cmd, slave *os.File) error {
+	return errNoPty
+}
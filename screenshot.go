@@ -0,0 +1,234 @@
+package gopanes
+
+import (
+	"fmt"
+	"github.com/mattn/go-runewidth"
+	"github.com/nsf/termbox-go"
+	"html"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// the size of a cell in SVG screenshots, in pixels
+const (
+	svgCellWidth  = 9
+	svgCellHeight = 18
+	svgFontSize   = 15
+)
+
+type screenCell struct {
+	Ch    rune
+	Style Style
+	Dim   bool
+}
+
+// Screenshot is a copy of the whole screen as it was drawn: panes, dividers,
+// the status bar and any overlay
+type Screenshot struct {
+	Width  int
+	Height int
+	cells  []screenCell
+}
+
+// Screenshot captures the screen as it was last drawn
+func (gu *GoPaneUi) Screenshot() *Screenshot {
+	termboxMutex.Lock()
+	defer termboxMutex.Unlock()
+	width, height := termbox.Size()
+	mode := termbox.SetOutputMode(termbox.OutputCurrent)
+	return newScreenshot(width, height, termbox.CellBuffer(), mode)
+}
+
+// Render draws the panes, status bar and any overlay off screen at the given
+// size and returns the result, without needing a terminal. The panes are laid
+// out for the window again afterwards.
+func (gu *GoPaneUi) Render(width, height int) *Screenshot {
+	termboxMutex.Lock()
+	defer termboxMutex.Unlock()
+	ms := newMemoryScreen(width, height)
+	previous := drawScreen
+	drawScreen = ms
+	gu.Root.setBounds(gu.paneArea())
+	gu.render()
+	drawScreen = previous
+	gu.Root.setBounds(gu.paneArea())
+	mode := termbox.SetOutputMode(termbox.OutputCurrent)
+	return newScreenshot(width, height, ms.cells, mode)
+}
+
+func newScreenshot(width, height int, cells []termbox.Cell, mode termbox.OutputMode) *Screenshot {
+	s := &Screenshot{Width: width, Height: height, cells: make([]screenCell, len(cells))}
+	for idx, cell := range cells {
+		style := NewStyle().Fg(attributeColor(cell.Fg, mode)).Bg(attributeColor(cell.Bg, mode))
+		if cell.Fg&termbox.AttrBold != 0 {
+			style = style.Bold()
+		}
+		if cell.Fg&termbox.AttrUnderline != 0 {
+			style = style.Underline()
+		}
+		if cell.Fg&termbox.AttrReverse != 0 {
+			style = style.Reverse()
+		}
+		if cell.Fg&termbox.AttrBlink != 0 {
+			style = style.Blink()
+		}
+		ch := cell.Ch
+		if ch == 0 {
+			ch = ' '
+		}
+		s.cells[idx] = screenCell{Ch: ch, Style: style, Dim: cell.Fg&termbox.AttrDim != 0}
+	}
+	return s
+}
+
+// attributeColor turns the color of a termbox attribute back into a TermColor
+func attributeColor(attr termbox.Attribute, mode termbox.OutputMode) TermColor {
+	// RGB colors are stored above the attribute bits
+	if mode == termbox.OutputRGB && attr >= termbox.AttrReverse<<1 {
+		return ColorRGB(termbox.AttributeToRGB(attr))
+	}
+	color := attr & (termbox.AttrBold - 1)
+	if color == termbox.ColorDefault {
+		return ColorDefault
+	}
+	switch mode {
+	case termbox.Output256:
+		return Color256(uint8(color - 1))
+	case termbox.Output216:
+		// the 6x6x6 cube starts at color 16
+		return Color256(uint8(color + 15))
+	case termbox.OutputGrayscale:
+		// black, the 24 step gray ramp starting at color 232, then white
+		switch color &= 0x1f; {
+		case color <= 1:
+			return Color256(16)
+		case color >= 26:
+			return Color256(231)
+		}
+		return Color256(uint8(color + 230))
+	}
+	return colorKindBasic | TermColor(color-1)&0xf
+}
+
+// screenRun is a run of cells in the same style
+type screenRun struct {
+	x     int // the column the run starts at
+	width int // in columns
+	text  string
+	style Style
+	dim   bool
+}
+
+// runs splits a row into runs of cells in the same style. The cells wide runes
+// spill into are skipped.
+func (s *Screenshot) runs(y int) []screenRun {
+	var runs []screenRun
+	for x := 0; x < s.Width; {
+		cell := s.cells[y*s.Width+x]
+		w := runewidth.RuneWidth(cell.Ch)
+		if w < 1 {
+			w = 1
+		}
+		if n := len(runs); n > 0 && runs[n-1].style == cell.Style && runs[n-1].dim == cell.Dim {
+			runs[n-1].text += string(cell.Ch)
+			runs[n-1].width += w
+		} else {
+			runs = append(runs, screenRun{x: x, width: w, text: string(cell.Ch), style: cell.Style, dim: cell.Dim})
+		}
+		x += w
+	}
+	return runs
+}
+
+// Text returns the screen's characters as a grid, with trailing spaces
+// removed from each row
+func (s *Screenshot) Text() string {
+	var text strings.Builder
+	for y := 0; y < s.Height; y++ {
+		var row strings.Builder
+		for _, run := range s.runs(y) {
+			row.WriteString(run.text)
+		}
+		text.WriteString(strings.TrimRight(row.String(), " "))
+		text.WriteString("\n")
+	}
+	return text.String()
+}
+
+// HTML returns the screen as a standalone HTML page
+func (s *Screenshot) HTML() string {
+	var out strings.Builder
+	writeHTMLHeader(&out)
+	for y := 0; y < s.Height; y++ {
+		for _, run := range s.runs(y) {
+			css := run.style.css()
+			if run.dim {
+				css = strings.TrimPrefix(css+";opacity:0.5", ";")
+			}
+			if css == "" {
+				out.WriteString(html.EscapeString(run.text))
+			} else {
+				fmt.Fprintf(&out, "<span style=\"%s\">%s</span>", css, html.EscapeString(run.text))
+			}
+		}
+		out.WriteString("\n")
+	}
+	out.WriteString(htmlFooter)
+	return out.String()
+}
+
+// SVG returns the screen as an SVG image
+func (s *Screenshot) SVG() string {
+	var out strings.Builder
+	width, height := s.Width*svgCellWidth, s.Height*svgCellHeight
+	fmt.Fprintf(&out, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n",
+		width, height, width, height)
+	fmt.Fprintf(&out, "<rect width=\"100%%\" height=\"100%%\" fill=\"%s\"/>\n", htmlDefaultBg)
+	fmt.Fprintf(&out, "<g font-family=\"monospace\" font-size=\"%d\" xml:space=\"preserve\">\n", svgFontSize)
+	for y := 0; y < s.Height; y++ {
+		for _, run := range s.runs(y) {
+			fg, bg := run.style.cssColors()
+			if bg != "" {
+				fmt.Fprintf(&out, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\"/>\n",
+					run.x*svgCellWidth, y*svgCellHeight, run.width*svgCellWidth, svgCellHeight, bg)
+			}
+			if strings.TrimSpace(run.text) == "" {
+				continue
+			}
+			if fg == "" {
+				fg = htmlDefaultFg
+			}
+			attrs := fmt.Sprintf("fill=\"%s\"", fg)
+			if run.style.IsBold() {
+				attrs += " font-weight=\"bold\""
+			}
+			if run.style.IsUnderline() {
+				attrs += " text-decoration=\"underline\""
+			}
+			if run.dim {
+				attrs += " opacity=\"0.5\""
+			}
+			// the baseline sits a little above the bottom of the cell
+			fmt.Fprintf(&out, "<text x=\"%d\" y=\"%d\" textLength=\"%d\" lengthAdjust=\"spacingAndGlyphs\" %s>%s</text>\n",
+				run.x*svgCellWidth, (y+1)*svgCellHeight-4, run.width*svgCellWidth, attrs, html.EscapeString(run.text))
+		}
+	}
+	out.WriteString("</g>\n</svg>\n")
+	return out.String()
+}
+
+// Save writes the screenshot to a file, as SVG or HTML if the file name ends
+// in .svg or .html and as text otherwise
+func (s *Screenshot) Save(path string) error {
+	var data string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".svg":
+		data = s.SVG()
+	case ".html", ".htm":
+		data = s.HTML()
+	default:
+		data = s.Text()
+	}
+	return ioutil.WriteFile(path, []byte(data), 0644)
+}
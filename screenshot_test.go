@@ -0,0 +1,74 @@
+package gopanes
+
+import (
+	"github.com/nsf/termbox-go"
+	"strings"
+	"testing"
+)
+
+func TestScreenshot(t *testing.T) {
+	cells := []termbox.Cell{
+		{Ch: 'a', Fg: termbox.ColorRed | termbox.AttrBold}, {Ch: '世'}, {Ch: 0}, {Ch: ' '},
+		{Ch: 'b', Bg: termbox.ColorBlue}, {Ch: 'c', Fg: termbox.AttrDim}, {Ch: ' '}, {Ch: ' '},
+	}
+	s := newScreenshot(4, 2, cells, termbox.OutputNormal)
+	if got, want := s.Text(), "a世\nbc\n"; got != want {
+		t.Errorf("Text() = %q, want %q", got, want)
+	}
+	html := s.HTML()
+	for _, want := range []string{
+		`<span style="color:#cd0000;font-weight:bold">a</span>世 `,
+		`<span style="background-color:#0000ee">b</span><span style="opacity:0.5">c</span>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("HTML() = %q, doesn't contain %q", html, want)
+		}
+	}
+	if svg := s.SVG(); !strings.Contains(svg, `<rect x="0" y="18" width="9" height="18" fill="#0000ee"/>`) {
+		t.Errorf("SVG() = %q, is missing the background of b", svg)
+	}
+
+	if got := attributeColor(termbox.RGBToAttribute(1, 2, 3), termbox.OutputRGB); got != ColorRGB(1, 2, 3) {
+		t.Errorf("RGB attribute color = %x", got)
+	}
+	if got := attributeColor(termbox.Attribute(203)|termbox.AttrBold, termbox.Output256); got != Color256(202) {
+		t.Errorf("256 attribute color = %x", got)
+	}
+	for _, test := range []struct {
+		attr termbox.Attribute
+		mode termbox.OutputMode
+		want TermColor
+	}{
+		{1, termbox.Output216, Color256(16)},
+		{216, termbox.Output216, Color256(231)},
+		{1, termbox.OutputGrayscale, Color256(16)},
+		{2, termbox.OutputGrayscale, Color256(232)},
+		{25, termbox.OutputGrayscale, Color256(255)},
+		{26, termbox.OutputGrayscale, Color256(231)},
+	} {
+		if got := attributeColor(test.attr, test.mode); got != test.want {
+			t.Errorf("attribute %d in mode %d: color = %x, want %x", test.attr, test.mode, got, test.want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	ui, _ := newTestUi(t, 20, 6)
+	ui.Root.Vert(5)
+	ui.Root.First.SetTitle("left")
+	ui.Root.First.AddLine([]ColorStr{Color.Red("one")})
+	ui.Root.Second.AddLine([]ColorStr{Color.Default("two")})
+	s := ui.Render(12, 3)
+	if got, want := s.Text(), " lef │two\none  │\n     │\n"; got != want {
+		t.Errorf("Text() = %q, want %q", got, want)
+	}
+	if html := s.HTML(); !strings.Contains(html, `<span style="color:#cd0000">one</span>`) {
+		t.Errorf("HTML() = %q, is missing the red line", html)
+	}
+	if s.Width != 12 || s.Height != 3 {
+		t.Errorf("screenshot is %dx%d, want 12x3", s.Width, s.Height)
+	}
+	if ui.Root.width != 20 || ui.Root.height != 6 {
+		t.Errorf("panes are laid out at %dx%d after rendering, want 20x6", ui.Root.width, ui.Root.height)
+	}
+}
@@ -0,0 +1,399 @@
+package gopanes
+
+import (
+	"fmt"
+	"github.com/nsf/termbox-go"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// logSearch is the state of a search through a content log's lines
+type logSearch struct {
+	query   string
+	regex   bool
+	editing bool // the prompt is taking the query
+	pattern *regexp.Regexp
+	err     error // why the query isn't a valid regex
+	matches []logMatch
+	current int // index into matches, -1 before jumping to one
+	// the indices into matches of each line's matches, so drawing a line
+	// doesn't go through all of them
+	byLine map[*contentLine][]int
+}
+
+type logMatch struct {
+	line  *contentLine
+	start int // rune offsets into the line's text
+	end   int
+}
+
+// compile turns the query into a pattern, quoting it unless it's a regex
+func (s *logSearch) compile() {
+	s.pattern, s.err = nil, nil
+	if s.query == "" {
+		return
+	}
+	expr := s.query
+	if !s.regex {
+		expr = regexp.QuoteMeta(expr)
+	}
+	s.pattern, s.err = regexp.Compile(expr)
+}
+
+// lineText returns a line's text without styles
+func lineText(colorStrs []ColorStr) string {
+	var text strings.Builder
+	for _, colorStr := range colorStrs {
+		text.WriteString(colorStr.Str)
+	}
+	return text.String()
+}
+
+// find collects the matches in the content, keeping the current match on the
+// same line if it's still there
+func (s *logSearch) find(content []*contentLine) {
+	var current logMatch
+	if s.current >= 0 && s.current < len(s.matches) {
+		current = s.matches[s.current]
+	}
+	s.matches = s.matches[:0]
+	s.byLine = make(map[*contentLine][]int)
+	if s.pattern == nil {
+		s.current = -1
+		return
+	}
+	for _, line := range content {
+		text := lineText(line.colorStrs)
+		for _, loc := range s.pattern.FindAllStringIndex(text, -1) {
+			// empty matches can't be highlighted
+			if loc[0] == loc[1] {
+				continue
+			}
+			start := utf8.RuneCountInString(text[:loc[0]])
+			end := start + utf8.RuneCountInString(text[loc[0]:loc[1]])
+			s.byLine[line] = append(s.byLine[line], len(s.matches))
+			s.matches = append(s.matches, logMatch{line: line, start: start, end: end})
+		}
+	}
+	if s.current < 0 {
+		return
+	}
+	s.current = -1
+	for idx, match := range s.matches {
+		if match.line == current.line && match.start >= current.start {
+			s.current = idx
+			break
+		}
+	}
+	if s.current < 0 && len(s.matches) > 0 {
+		s.current = len(s.matches) - 1
+	}
+}
+
+// highlight returns the line with its matches in the match styles, or nil if
+// it has none
+func (s *logSearch) highlight(line *contentLine, matchStyle, currentStyle Style) []ColorStr {
+	indices := s.byLine[line]
+	if len(indices) == 0 {
+		return nil
+	}
+	ranges := make([]logMatch, len(indices))
+	var current logMatch
+	for i, idx := range indices {
+		ranges[i] = s.matches[idx]
+		if idx == s.current {
+			current = s.matches[idx]
+		}
+	}
+	var highlighted []ColorStr
+	pos := 0
+	for _, colorStr := range line.colorStrs {
+		for _, r := range colorStr.Str {
+			style := colorStr.Style
+			for _, match := range ranges {
+				if pos >= match.start && pos < match.end {
+					if match == current {
+						style = inheritStyle(currentStyle, style)
+					} else {
+						style = inheritStyle(matchStyle, style)
+					}
+					break
+				}
+			}
+			highlighted = append(highlighted, style.Str(string(r)))
+			pos++
+		}
+	}
+	return highlighted
+}
+
+// StartSearch shows a search prompt at the bottom of the log. Typing there
+// searches the log's lines as you go, Ctrl-R switches between literal and
+// regex queries, Enter closes the prompt leaving the matches highlighted, n
+// and N then jump to the next and previous match and / reopens the prompt.
+// Esc ends the search.
+func (cl *ContentLog) StartSearch() {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	cl.copyMode = nil
+	if cl.search == nil {
+		cl.search = &logSearch{current: -1}
+	}
+	cl.search.editing = true
+}
+
+// Search highlights the matches of a query in the log and jumps to the last
+// one. It returns an error if the query is an invalid regex.
+func (cl *ContentLog) Search(query string, regex bool) error {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	cl.search = &logSearch{query: query, regex: regex, current: -1}
+	cl.search.compile()
+	if err := cl.search.err; err != nil {
+		cl.search = nil
+		return err
+	}
+	cl.jumpToLast()
+	return nil
+}
+
+// EndSearch closes the search prompt and removes the highlights
+func (cl *ContentLog) EndSearch() {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	if cl.search != nil && cl.search.editing {
+		termbox.HideCursor()
+	}
+	cl.search = nil
+}
+
+// Matches returns the number of matches of the current search
+func (cl *ContentLog) Matches() int {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	if cl.search == nil {
+		return 0
+	}
+	cl.search.find(cl.content)
+	return len(cl.search.matches)
+}
+
+// NextMatch scrolls to the next match, going back to the first after the
+// last. It returns false if there are no matches.
+func (cl *ContentLog) NextMatch() bool {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	return cl.jumpBy(1)
+}
+
+// PrevMatch scrolls to the previous match, going round to the last after the
+// first. It returns false if there are no matches.
+func (cl *ContentLog) PrevMatch() bool {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	return cl.jumpBy(-1)
+}
+
+// the caller must hold contentLock
+func (cl *ContentLog) jumpBy(delta int) bool {
+	if cl.search == nil {
+		return false
+	}
+	cl.search.find(cl.content)
+	count := len(cl.search.matches)
+	if count == 0 {
+		return false
+	}
+	if cl.search.current < 0 {
+		cl.search.current = count - 1
+	} else {
+		cl.search.current = ((cl.search.current+delta)%count + count) % count
+	}
+	cl.scrollToMatch(cl.search.matches[cl.search.current])
+	return true
+}
+
+// the caller must hold contentLock
+func (cl *ContentLog) jumpToLast() {
+	cl.search.current = -1
+	cl.jumpBy(0)
+}
+
+// scrollToMatch scrolls the match into the middle of the view. The caller must
+// hold contentLock.
+func (cl *ContentLog) scrollToMatch(match logMatch) {
+	params := cl.wrapParams(cl.width)
+	totalRows, matchRow := 0, 0
+	for _, line := range cl.content {
+		if line == match.line {
+			// the rows of the line before the one the match starts on
+			var before []ColorStr
+			pos := 0
+			for _, colorStr := range line.colorStrs {
+				for _, r := range colorStr.Str {
+					if pos < match.start {
+						before = append(before, colorStr.Style.Str(string(r)))
+					}
+					pos++
+				}
+			}
+			beforeRows := wrapLine(before, params.width, params.mode, params.marker)
+			matchRow = totalRows + len(beforeRows) - 1
+			if cl.wrapMode == WrapNone {
+				cl.scrollToColumn(rowWidth(beforeRows[len(beforeRows)-1]))
+			}
+		}
+		totalRows += len(line.wrap(params))
+	}
+	startRow := matchRow - cl.height/2
+	cl.scrollOffset = totalRows - cl.height - startRow
+	if cl.scrollOffset > totalRows-cl.height {
+		cl.scrollOffset = totalRows - cl.height
+	}
+	if cl.scrollOffset < 0 {
+		cl.scrollOffset = 0
+	}
+}
+
+// scrollToColumn scrolls a log that doesn't wrap sideways so the column is
+// visible. The caller must hold contentLock.
+func (cl *ContentLog) scrollToColumn(col int) {
+	if col >= cl.hScroll && col < cl.hScroll+cl.width-1 {
+		return
+	}
+	cl.hScroll = col - cl.width/3
+	if cl.hScroll < 0 {
+		cl.hScroll = 0
+	}
+}
+
+// handleSearchKey handles keys for the search, returning false for keys it
+// doesn't use
+func (cl *ContentLog) handleSearchKey(ev termbox.Event) bool {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	s := cl.search
+	if s == nil {
+		return false
+	}
+	if !s.editing {
+		switch {
+		case ev.Key == termbox.KeyEsc:
+			cl.search = nil
+		case ev.Ch == 'n':
+			cl.jumpBy(1)
+		case ev.Ch == 'N':
+			cl.jumpBy(-1)
+		case ev.Ch == '/':
+			s.editing = true
+		default:
+			return false
+		}
+		return true
+	}
+	switch ev.Key {
+	case termbox.KeyEsc:
+		termbox.HideCursor()
+		cl.search = nil
+		return true
+	case termbox.KeyEnter:
+		termbox.HideCursor()
+		s.editing = false
+		return true
+	case termbox.KeyCtrlR:
+		s.regex = !s.regex
+	case termbox.KeyBackspace, termbox.KeyBackspace2:
+		if s.query == "" {
+			return true
+		}
+		_, size := utf8.DecodeLastRuneInString(s.query)
+		s.query = s.query[:len(s.query)-size]
+	case termbox.KeySpace:
+		s.query += " "
+	default:
+		if ev.Ch == 0 {
+			return true
+		}
+		s.query += string(ev.Ch)
+	}
+	// search as the query is typed
+	s.compile()
+	cl.jumpToLast()
+	return true
+}
+
+// drawSearchPrompt draws the search prompt on the bottom row of the canvas.
+// The caller must hold contentLock.
+func (cl *ContentLog) drawSearchPrompt(c *Canvas) {
+	s := cl.search
+	y := c.Height() - 1
+	c.Fill(0, y, c.Width(), 1, ' ', NewStyle())
+	prompt := "/"
+	if s.regex {
+		prompt = "regex/"
+	}
+	end := c.Print(0, y, prompt, NewStyle().Bold())
+	end += c.Print(end, y, s.query, NewStyle())
+	if s.editing && cl.isFocused {
+		c.SetCursor(end, y)
+	}
+	var status ColorStr
+	switch {
+	case s.err != nil:
+		status = NewStyle().Fg(ColorRed).Str(s.err.Error())
+	case s.pattern == nil:
+		return
+	case len(s.matches) == 0:
+		status = NewStyle().Fg(ColorRed).Str("no matches")
+	case s.current < 0:
+		status = NewStyle().Str(fmt.Sprintf("[%d]", len(s.matches)))
+	default:
+		status = NewStyle().Str(fmt.Sprintf("[%d/%d]", s.current+1, len(s.matches)))
+	}
+	statusWidth := colorStrsWidth([]ColorStr{status})
+	x := c.Width() - statusWidth
+	if x < end+1 {
+		x = end + 1
+	}
+	c.PrintColorStrs(x, y, []ColorStr{status})
+}
+
+func (gp *GoPane) StartSearch() {
+	if log := gp.ContentLog(); log != nil {
+		log.StartSearch()
+		gp.Refresh()
+	}
+}
+
+func (gp *GoPane) Search(query string, regex bool) error {
+	log := gp.ContentLog()
+	if log == nil {
+		return nil
+	}
+	defer gp.Refresh()
+	return log.Search(query, regex)
+}
+
+func (gp *GoPane) EndSearch() {
+	if log := gp.ContentLog(); log != nil {
+		log.EndSearch()
+		gp.Refresh()
+	}
+}
+
+func (gp *GoPane) NextMatch() bool {
+	if log := gp.ContentLog(); log != nil {
+		defer gp.Refresh()
+		return log.NextMatch()
+	}
+	return false
+}
+
+func (gp *GoPane) PrevMatch() bool {
+	if log := gp.ContentLog(); log != nil {
+		defer gp.Refresh()
+		return log.PrevMatch()
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package gopanes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSearchMatches(t *testing.T) {
+	log := NewContentLog()
+	log.AddLine([]ColorStr{NewStyle().Str("error: "), NewStyle().Bold().Str("disk full")})
+	log.AddLine([]ColorStr{NewStyle().Str("ok")})
+	log.AddLine([]ColorStr{NewStyle().Str("error 42, error 43")})
+	if err := log.Search("error", false); err != nil {
+		t.Fatal(err)
+	}
+	if got := log.Matches(); got != 3 {
+		t.Errorf("Matches() = %d, want 3", got)
+	}
+	if err := log.Search(`error \d+`, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := log.Matches(); got != 2 {
+		t.Errorf("regex Matches() = %d, want 2", got)
+	}
+	if err := log.Search("error (", true); err == nil {
+		t.Error("invalid regex didn't return an error")
+	}
+
+	// matches are highlighted over the line's own styles
+	log.Search("r: d", false)
+	log.Matches()
+	highlighted := log.search.highlight(log.content[0], NewStyle().Bg(ColorYellow), NewStyle().Bg(ColorRed))
+	var got []bool
+	for _, colorStr := range highlighted {
+		got = append(got, colorStr.Style.Background() == ColorRed)
+	}
+	want := []bool{false, false, false, false, true, true, true, true, false, false, false, false, false, false, false, false}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("highlighted runes = %v, want %v", got, want)
+		}
+	}
+	if !highlighted[7].Style.IsBold() {
+		t.Error("highlighting lost the line's bold style")
+	}
+}
+
+func TestSearchHighlightsByLine(t *testing.T) {
+	log := NewContentLog()
+	log.AddLine([]ColorStr{NewStyle().Str("ab ab")})
+	log.AddLine([]ColorStr{NewStyle().Str("cd")})
+	log.AddLine([]ColorStr{NewStyle().Str("ab")})
+	log.Search("ab", false)
+	log.Matches()
+	matchStyle, currentStyle := NewStyle().Bg(ColorYellow), NewStyle().Bg(ColorRed)
+	if got := log.search.highlight(log.content[1], matchStyle, currentStyle); got != nil {
+		t.Errorf("a line without matches was highlighted: %v", got)
+	}
+	// the search jumped to the last match, on the last line
+	backgrounds := func(line int) (bgs []TermColor) {
+		for _, colorStr := range log.search.highlight(log.content[line], matchStyle, currentStyle) {
+			bgs = append(bgs, colorStr.Style.Background())
+		}
+		return bgs
+	}
+	y, r, d := ColorYellow, ColorRed, ColorDefault
+	if got, want := backgrounds(0), []TermColor{y, y, d, y, y}; !reflect.DeepEqual(got, want) {
+		t.Errorf("first line backgrounds = %v, want %v", got, want)
+	}
+	if got, want := backgrounds(2), []TermColor{r, r}; !reflect.DeepEqual(got, want) {
+		t.Errorf("last line backgrounds = %v, want %v", got, want)
+	}
+}
+
+func TestSearchWhileDrawing(t *testing.T) {
+	log := NewContentLog()
+	log.AddLine([]ColorStr{NewStyle().Str("line")})
+	ms := newMemoryScreen(10, 3)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			log.StartSearch()
+			log.EndSearch()
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		log.Draw(newCanvasOn(ms, 0, 0, 10, 3))
+	}
+	<-done
+}
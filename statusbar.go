@@ -0,0 +1,231 @@
+package gopanes
+
+import (
+	"github.com/mattn/go-runewidth"
+	"time"
+)
+
+// A StatusSegment produces part of the status bar's text. It is called every
+// time the status bar is drawn, so it can show changing information.
+//
+// Segments are called while the screen is locked for drawing. A segment must
+// not call back into the UI, such as Refresh, RefreshStatusBar, Screenshot or
+// anything else that draws, or the UI deadlocks.
+type StatusSegment func(gu *GoPaneUi) []ColorStr
+
+// TextSegment always shows the same text
+func TextSegment(colorStrs ...ColorStr) StatusSegment {
+	return func(gu *GoPaneUi) []ColorStr {
+		return colorStrs
+	}
+}
+
+// ClockSegment shows the current time in the given time.Format layout
+func ClockSegment(layout string) StatusSegment {
+	return func(gu *GoPaneUi) []ColorStr {
+		return []ColorStr{Color.Default(time.Now().Format(layout))}
+	}
+}
+
+// PrefixSegment shows the indicator while the prefix key has been pressed and
+// the UI is waiting for a command
+func PrefixSegment(indicator ...ColorStr) StatusSegment {
+	return func(gu *GoPaneUi) []ColorStr {
+		if gu.prefixPending {
+			return indicator
+		}
+		return nil
+	}
+}
+
+// MessageSegment shows the result of the last prefix command that reports
+// one, such as the file the prefix key followed by S saved the focused pane's
+// content to, until the prefix key is pressed again
+func MessageSegment() StatusSegment {
+	return func(gu *GoPaneUi) []ColorStr {
+		return gu.message
+	}
+}
+
+// showMessage reports the result of a prefix command in the status bar
+func (gu *GoPaneUi) showMessage(message ...ColorStr) {
+	termboxMutex.Lock()
+	gu.message = message
+	termboxMutex.Unlock()
+	gu.RefreshStatusBar()
+}
+
+// setPrefixPending records whether the UI is waiting for a prefix command.
+// Pressing the prefix key clears the last command's message.
+func (gu *GoPaneUi) setPrefixPending(pending bool) {
+	termboxMutex.Lock()
+	defer termboxMutex.Unlock()
+	gu.prefixPending = pending
+	if pending {
+		gu.message = nil
+	}
+}
+
+// FocusedTitleSegment shows the title of the focused pane
+func FocusedTitleSegment() StatusSegment {
+	return func(gu *GoPaneUi) []ColorStr {
+		if focused := gu.GetFocusedPane(); focused != nil && focused.title != "" {
+			return []ColorStr{Color.Default(focused.title)}
+		}
+		return nil
+	}
+}
+
+type StatusPosition int
+
+const (
+	StatusBottom StatusPosition = iota
+	StatusTop
+)
+
+// StatusBar is a row at the top or bottom of the window, like tmux's status
+// line. Segments in each group are separated by a space.
+type StatusBar struct {
+	Position StatusPosition
+	Left     []StatusSegment
+	Center   []StatusSegment
+	Right    []StatusSegment
+	// the style of the whole row, segments with default colors inherit it
+	Style Style
+	// how often the status bar is redrawn, defaults to once a second
+	Interval time.Duration
+}
+
+// SetStatusBar shows the status bar and shrinks the panes to make room for
+// it. A nil status bar removes it again.
+func (gu *GoPaneUi) SetStatusBar(sb *StatusBar) {
+	if gu.statusStop != nil {
+		close(gu.statusStop)
+		gu.statusStop = nil
+	}
+	// the old ticker may still be drawing
+	termboxMutex.Lock()
+	gu.statusBar = sb
+	termboxMutex.Unlock()
+	if sb != nil {
+		interval := sb.Interval
+		if interval <= 0 {
+			interval = time.Second
+		}
+		gu.statusStop = make(chan struct{})
+		go gu.statusTicker(interval, gu.statusStop)
+	}
+	gu.Resize()
+}
+
+func (gu *GoPaneUi) statusTicker(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			gu.RefreshStatusBar()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// paneArea returns the part of the window the panes get
+func (gu *GoPaneUi) paneArea() (x, y, width, height int) {
+	width, height = gu.getWindowWidth(), gu.getWindowHeight()
+	if gu.statusBar == nil || height < 1 {
+		return 0, 0, width, height
+	}
+	if gu.statusBar.Position == StatusTop {
+		return 0, 1, width, height - 1
+	}
+	return 0, 0, width, height - 1
+}
+
+// RefreshStatusBar redraws just the status bar
+func (gu *GoPaneUi) RefreshStatusBar() {
+	termboxMutex.Lock()
+	defer termboxMutex.Unlock()
+	gu.drawStatusBar()
+	drawScreen.Flush()
+}
+
+// inheritStyle fills in the default colors of s from base
+func inheritStyle(s, base Style) Style {
+	if s.fg.IsDefault() {
+		s.fg = base.fg
+	}
+	if s.bg.IsDefault() {
+		s.bg = base.bg
+	}
+	s.attrs |= base.attrs
+	return s
+}
+
+// renderSegments runs the segments and joins their output with spaces
+func (gu *GoPaneUi) renderSegments(segments []StatusSegment) []ColorStr {
+	var colorStrs []ColorStr
+	for _, segment := range segments {
+		out := segment(gu)
+		if len(out) == 0 {
+			continue
+		}
+		if len(colorStrs) > 0 {
+			colorStrs = append(colorStrs, Color.Default(" "))
+		}
+		colorStrs = append(colorStrs, out...)
+	}
+	return colorStrs
+}
+
+func colorStrsWidth(colorStrs []ColorStr) int {
+	width := 0
+	for _, colorStr := range colorStrs {
+		width += runewidth.StringWidth(colorStr.Str)
+	}
+	return width
+}
+
+// printInherited prints colorStrs with their default colors taken from base
+func printInherited(c *Canvas, base Style, colorStrs []ColorStr) {
+	inherited := make([]ColorStr, len(colorStrs))
+	for idx, colorStr := range colorStrs {
+		inherited[idx] = inheritStyle(colorStr.Style, base).Str(colorStr.Str)
+	}
+	c.PrintColorStrs(0, 0, inherited)
+}
+
+// the caller must hold termboxMutex
+func (gu *GoPaneUi) drawStatusBar() {
+	sb := gu.statusBar
+	if sb == nil {
+		return
+	}
+	width, height := gu.getWindowWidth(), gu.getWindowHeight()
+	y := height - 1
+	if sb.Position == StatusTop {
+		y = 0
+	}
+	c := NewCanvas(0, y, width, 1)
+	c.Clear(sb.Style)
+
+	left := gu.renderSegments(sb.Left)
+	center := gu.renderSegments(sb.Center)
+	right := gu.renderSegments(sb.Right)
+	leftWidth := colorStrsWidth(left)
+	rightWidth := colorStrsWidth(right)
+	centerWidth := colorStrsWidth(center)
+
+	// the right side wins over the left, the center only shows if it fits
+	rightX := width - rightWidth
+	if rightX < 0 {
+		rightX = 0
+	}
+	printInherited(c.Sub(0, 0, rightX, 1), sb.Style, left)
+	printInherited(c.Sub(rightX, 0, rightWidth, 1), sb.Style, right)
+	centerX := (width - centerWidth) / 2
+	if centerX >= leftWidth && centerX+centerWidth <= rightX {
+		printInherited(c.Sub(centerX, 0, centerWidth, 1), sb.Style, center)
+	}
+}
@@ -0,0 +1,77 @@
+package gopanes
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatusBarSegments(t *testing.T) {
+	ui, ms := newTestUi(t, 20, 3)
+	ui.SetStatusBar(&StatusBar{
+		Left:     []StatusSegment{TextSegment(Color.Default("left")), PrefixSegment(Color.Red("^G"))},
+		Center:   []StatusSegment{TextSegment(Color.Default("mid"))},
+		Right:    []StatusSegment{TextSegment(Color.Default("right"))},
+		Style:    NewStyle().Bg(ColorBlue),
+		Interval: time.Hour,
+	})
+	if got, want := screenRows(ms)[2], "left    mid    right"; got != want {
+		t.Errorf("status bar = %q, want %q", got, want)
+	}
+	ui.setPrefixPending(true)
+	ui.RefreshStatusBar()
+	if got, want := screenRows(ms)[2], "left ^G mid    right"; got != want {
+		t.Errorf("status bar with the prefix pending = %q, want %q", got, want)
+	}
+	// segments with default colors take the bar's
+	fg, bg := NewStyle().Fg(ColorRed).Bg(ColorBlue).attributes()
+	if cell := ms.cells[2*20+5]; cell.Fg != fg || cell.Bg != bg {
+		t.Errorf("prefix cell colors = %v, %v, want %v, %v", cell.Fg, cell.Bg, fg, bg)
+	}
+}
+
+func TestStatusBarCenterHidden(t *testing.T) {
+	ui, ms := newTestUi(t, 11, 1)
+	ui.SetStatusBar(&StatusBar{
+		Left:     []StatusSegment{TextSegment(Color.Default("left"))},
+		Center:   []StatusSegment{TextSegment(Color.Default("mid"))},
+		Right:    []StatusSegment{TextSegment(Color.Default("right"))},
+		Interval: time.Hour,
+	})
+	if got, want := screenRows(ms)[0], "left  right"; got != want {
+		t.Errorf("status bar = %q, want %q", got, want)
+	}
+}
+
+func TestStatusBarLayout(t *testing.T) {
+	ui, _ := newTestUi(t, 20, 10)
+	bounds := func() [4]int {
+		return [4]int{ui.Root.x, ui.Root.y, ui.Root.width, ui.Root.height}
+	}
+	ui.SetStatusBar(&StatusBar{Interval: time.Hour})
+	if got, want := bounds(), [4]int{0, 0, 20, 9}; got != want {
+		t.Errorf("with the bar at the bottom, panes are at %v, want %v", got, want)
+	}
+	ui.SetStatusBar(&StatusBar{Position: StatusTop, Interval: time.Hour})
+	if got, want := bounds(), [4]int{0, 1, 20, 9}; got != want {
+		t.Errorf("with the bar at the top, panes are at %v, want %v", got, want)
+	}
+	ui.SetStatusBar(nil)
+	if got, want := bounds(), [4]int{0, 0, 20, 10}; got != want {
+		t.Errorf("without a bar, panes are at %v, want %v", got, want)
+	}
+}
+
+func TestStatusBarStateWhileTicking(t *testing.T) {
+	ui, _ := newTestUi(t, 20, 3)
+	ui.SetStatusBar(&StatusBar{
+		Left:     []StatusSegment{PrefixSegment(Color.Red("^G")), MessageSegment()},
+		Interval: time.Millisecond,
+	})
+	// the ticker draws the bar while the prefix and message change
+	for i := 0; i < 50; i++ {
+		ui.setPrefixPending(true)
+		ui.setPrefixPending(false)
+		ui.showMessage(Color.Default("saved"))
+		time.Sleep(100 * time.Microsecond)
+	}
+}
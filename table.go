@@ -0,0 +1,369 @@
+package gopanes
+
+import (
+	"fmt"
+	"github.com/mattn/go-runewidth"
+	"github.com/nsf/termbox-go"
+	"sort"
+	"sync"
+)
+
+// ColumnPolicy is how a table column's width is chosen
+type ColumnPolicy int
+
+const (
+	// the column is as wide as its widest cell, up to TableColumn.Width if
+	// that isn't zero, the default
+	ColumnFit ColumnPolicy = iota
+	// the column is always TableColumn.Width wide
+	ColumnFixed
+	// the column shares the space the other columns leave, weighted by
+	// TableColumn.Width
+	ColumnProportional
+)
+
+type TableColumn struct {
+	Title  string
+	Policy ColumnPolicy
+	Width  int
+	// Less orders cells when sorting by the column, the default compares
+	// their text
+	Less func(a, b ColorStr) bool
+}
+
+// Table is a widget showing rows of cells in columns under a header row. The
+// arrow keys, j and k, PgUp/PgDn and Home/End move the cursor, h and l or
+// the left and right arrows scroll sideways when the columns don't fit, the
+// digits 1-9 sort by that column (again to reverse, 0 to stop sorting) and
+// Enter selects the row under the cursor.
+type Table struct {
+	columns        []TableColumn
+	rows           [][]ColorStr
+	rowsLock       sync.Mutex
+	order          []int // indices of rows in the order they're shown
+	sortColumn     int   // -1 when not sorted
+	sortDescending bool
+	cursor         int // index into order
+	offset         int // first visible row
+	hScroll        int
+	width          int // the size the table was last drawn at
+	height         int
+	isFocused      bool
+	onSelect       func(row []ColorStr)
+
+	HeaderStyle Style
+	CursorStyle Style
+}
+
+func NewTable(columns ...TableColumn) *Table {
+	return &Table{
+		columns:     columns,
+		sortColumn:  -1,
+		HeaderStyle: NewStyle().Bold().Underline(),
+		CursorStyle: NewStyle().Reverse(),
+	}
+}
+
+// AddRow appends a row with a cell for each column
+func (t *Table) AddRow(cells ...ColorStr) {
+	t.rowsLock.Lock()
+	defer t.rowsLock.Unlock()
+	t.rows = append(t.rows, cells)
+	t.sortRows()
+}
+
+// SetRows replaces all the rows
+func (t *Table) SetRows(rows [][]ColorStr) {
+	t.rowsLock.Lock()
+	defer t.rowsLock.Unlock()
+	t.rows = rows
+	t.sortRows()
+}
+
+// Rows returns the rows in the order they're shown
+func (t *Table) Rows() [][]ColorStr {
+	t.rowsLock.Lock()
+	defer t.rowsLock.Unlock()
+	rows := make([][]ColorStr, len(t.order))
+	for i, idx := range t.order {
+		rows[i] = t.rows[idx]
+	}
+	return rows
+}
+
+// SortBy sorts the rows by a column, or stops sorting for a negative column
+func (t *Table) SortBy(column int, descending bool) {
+	t.rowsLock.Lock()
+	defer t.rowsLock.Unlock()
+	if column >= len(t.columns) {
+		return
+	}
+	t.sortColumn = column
+	t.sortDescending = descending
+	t.sortRows()
+}
+
+// OnSelect sets a function called with the row under the cursor when Enter
+// is pressed. It's called from the UI's event loop, so it shouldn't block.
+func (t *Table) OnSelect(onSelect func(row []ColorStr)) {
+	t.onSelect = onSelect
+}
+
+// the caller must hold rowsLock
+func (t *Table) cell(row, column int) ColorStr {
+	if column < len(t.rows[row]) {
+		return t.rows[row][column]
+	}
+	return ColorStr{}
+}
+
+// sortRows works out the order to show the rows in. The caller must hold
+// rowsLock.
+func (t *Table) sortRows() {
+	t.order = make([]int, len(t.rows))
+	for i := range t.order {
+		t.order[i] = i
+	}
+	if t.sortColumn < 0 {
+		return
+	}
+	less := t.columns[t.sortColumn].Less
+	if less == nil {
+		less = func(a, b ColorStr) bool { return a.Str < b.Str }
+	}
+	sort.SliceStable(t.order, func(i, j int) bool {
+		a, b := t.cell(t.order[i], t.sortColumn), t.cell(t.order[j], t.sortColumn)
+		if t.sortDescending {
+			return less(b, a)
+		}
+		return less(a, b)
+	})
+}
+
+// columnWidths works out how wide each column is for the given total width.
+// The caller must hold rowsLock.
+func (t *Table) columnWidths(total int) []int {
+	widths := make([]int, len(t.columns))
+	// columns are separated by a space
+	remaining := total - (len(t.columns) - 1)
+	totalWeight := 0
+	for i, column := range t.columns {
+		switch column.Policy {
+		case ColumnFixed:
+			widths[i] = column.Width
+		case ColumnFit:
+			widths[i] = runewidth.StringWidth(column.Title)
+			for row := range t.rows {
+				if w := runewidth.StringWidth(t.cell(row, i).Str); w > widths[i] {
+					widths[i] = w
+				}
+			}
+			if column.Width > 0 && widths[i] > column.Width {
+				widths[i] = column.Width
+			}
+		case ColumnProportional:
+			totalWeight += column.Width
+			continue
+		}
+		remaining -= widths[i]
+	}
+	if remaining < 0 {
+		remaining = 0
+	}
+	for i, column := range t.columns {
+		if column.Policy != ColumnProportional {
+			continue
+		}
+		if totalWeight > 0 {
+			widths[i] = remaining * column.Width / totalWeight
+		}
+		if widths[i] < 1 {
+			widths[i] = 1
+		}
+	}
+	return widths
+}
+
+// truncate cuts a string to the given width, ending it with an ellipsis if
+// anything was cut
+func truncate(str string, width int) string {
+	if runewidth.StringWidth(str) <= width {
+		return str
+	}
+	return runewidth.Truncate(str, width, string(glyphs().ellipsis))
+}
+
+func (t *Table) HandleEvent(ev termbox.Event) {
+	t.rowsLock.Lock()
+	selected := t.handleEvent(ev)
+	t.rowsLock.Unlock()
+	// the callback may use the table, so it's called without the lock
+	if selected != nil && t.onSelect != nil {
+		t.onSelect(selected)
+	}
+}
+
+// handleEvent handles an event, returning the row selected if any. The caller
+// must hold rowsLock.
+func (t *Table) handleEvent(ev termbox.Event) []ColorStr {
+	if ev.Type == termbox.EventMouse {
+		switch ev.Key {
+		case termbox.MouseWheelUp:
+			t.moveCursor(t.cursor - 3)
+		case termbox.MouseWheelDown:
+			t.moveCursor(t.cursor + 3)
+		case termbox.MouseLeft:
+			// the header row is above the first row
+			if ev.MouseY > 0 {
+				t.moveCursor(t.offset + ev.MouseY - 1)
+			}
+		}
+		return nil
+	}
+	switch ev.Key {
+	case termbox.KeyArrowUp:
+		t.moveCursor(t.cursor - 1)
+	case termbox.KeyArrowDown:
+		t.moveCursor(t.cursor + 1)
+	case termbox.KeyPgup:
+		t.moveCursor(t.cursor - (t.height - 1))
+	case termbox.KeyPgdn:
+		t.moveCursor(t.cursor + (t.height - 1))
+	case termbox.KeyHome:
+		t.moveCursor(0)
+	case termbox.KeyEnd:
+		t.moveCursor(len(t.order) - 1)
+	case termbox.KeyArrowLeft:
+		t.scrollSideways(-4)
+	case termbox.KeyArrowRight:
+		t.scrollSideways(4)
+	case termbox.KeyEnter:
+		if t.cursor < len(t.order) {
+			return t.rows[t.order[t.cursor]]
+		}
+	default:
+		switch {
+		case ev.Ch == 'k':
+			t.moveCursor(t.cursor - 1)
+		case ev.Ch == 'j':
+			t.moveCursor(t.cursor + 1)
+		case ev.Ch == 'h':
+			t.scrollSideways(-4)
+		case ev.Ch == 'l':
+			t.scrollSideways(4)
+		case ev.Ch == '0':
+			t.sortColumn = -1
+			t.sortRows()
+		case ev.Ch >= '1' && ev.Ch <= '9' && int(ev.Ch-'1') < len(t.columns):
+			column := int(ev.Ch - '1')
+			// sorting by the same column again reverses it
+			t.sortDescending = column == t.sortColumn && !t.sortDescending
+			t.sortColumn = column
+			t.sortRows()
+		}
+	}
+	return nil
+}
+
+// the caller must hold rowsLock
+func (t *Table) moveCursor(idx int) {
+	if idx >= len(t.order) {
+		idx = len(t.order) - 1
+	}
+	if idx < 0 {
+		idx = 0
+	}
+	t.cursor = idx
+}
+
+// the caller must hold rowsLock
+func (t *Table) scrollSideways(cols int) {
+	total := 0
+	for _, w := range t.columnWidths(t.width) {
+		total += w + 1
+	}
+	t.hScroll += cols
+	if t.hScroll > total-1-t.width {
+		t.hScroll = total - 1 - t.width
+	}
+	if t.hScroll < 0 {
+		t.hScroll = 0
+	}
+}
+
+func (t *Table) Focus() { t.isFocused = true }
+func (t *Table) Blur()  { t.isFocused = false }
+
+// PreferredSize is the size the table would be with every column fitting
+func (t *Table) PreferredSize() (width, height int) {
+	t.rowsLock.Lock()
+	defer t.rowsLock.Unlock()
+	for _, w := range t.columnWidths(0) {
+		width += w + 1
+	}
+	return width - 1, len(t.rows) + 1
+}
+
+// ScrollPosition shows which row the cursor is on
+func (t *Table) ScrollPosition() string {
+	t.rowsLock.Lock()
+	defer t.rowsLock.Unlock()
+	if len(t.order) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("[%d/%d]", t.cursor+1, len(t.order))
+}
+
+func (t *Table) Draw(c *Canvas) {
+	t.rowsLock.Lock()
+	defer t.rowsLock.Unlock()
+	t.width, t.height = c.Width(), c.Height()
+	c.Clear(NewStyle())
+	widths := t.columnWidths(t.width)
+
+	// header row, with an arrow on the sorted column
+	x := -t.hScroll
+	for i, column := range t.columns {
+		title := column.Title
+		if i == t.sortColumn {
+			arrow := glyphs().upArrow
+			if t.sortDescending {
+				arrow = glyphs().downArrow
+			}
+			// the arrow takes the last cell, even of a column too narrow for it
+			width := widths[i] - 1
+			if width < 0 {
+				width = 0
+			}
+			title = truncate(title, width) + string(arrow)
+		}
+		c.Fill(x, 0, widths[i], 1, ' ', t.HeaderStyle)
+		c.Print(x, 0, truncate(title, widths[i]), t.HeaderStyle)
+		x += widths[i] + 1
+	}
+
+	// keep the cursor visible
+	visibleRows := t.height - 1
+	t.moveCursor(t.cursor)
+	if t.cursor < t.offset {
+		t.offset = t.cursor
+	}
+	if t.cursor >= t.offset+visibleRows {
+		t.offset = t.cursor - visibleRows + 1
+	}
+	for row := 0; row < visibleRows && t.offset+row < len(t.order); row++ {
+		idx := t.offset + row
+		rowStyle := NewStyle()
+		if idx == t.cursor && t.isFocused {
+			rowStyle = t.CursorStyle
+			c.Fill(0, row+1, t.width, 1, ' ', rowStyle)
+		}
+		x := -t.hScroll
+		for i := range t.columns {
+			cell := t.cell(t.order[idx], i)
+			style := inheritStyle(cell.Style, rowStyle)
+			c.Print(x, row+1, truncate(cell.Str, widths[i]), style)
+			x += widths[i] + 1
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package gopanes
+
+import (
+	"github.com/nsf/termbox-go"
+	"reflect"
+	"testing"
+)
+
+func TestColumnWidths(t *testing.T) {
+	table := NewTable(
+		TableColumn{Title: "id", Policy: ColumnFixed, Width: 4},
+		TableColumn{Title: "name", Policy: ColumnFit},
+		TableColumn{Title: "a", Policy: ColumnProportional, Width: 1},
+		TableColumn{Title: "b", Policy: ColumnProportional, Width: 3},
+	)
+	table.AddRow(NewStyle().Str("1"), NewStyle().Str("longer name"))
+	// 40 columns less 3 separators, 4 fixed and 11 fit leaves 22 to share
+	got := table.columnWidths(40)
+	want := []int{4, 11, 5, 16}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("columnWidths(40) = %v, want %v", got, want)
+	}
+	// with no room left the proportional columns still get a cell
+	got = table.columnWidths(10)
+	want = []int{4, 11, 1, 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("columnWidths(10) = %v, want %v", got, want)
+	}
+}
+
+func TestTableSort(t *testing.T) {
+	table := NewTable(TableColumn{Title: "name"}, TableColumn{Title: "size"})
+	table.AddRow(NewStyle().Str("b"), NewStyle().Str("2"))
+	table.AddRow(NewStyle().Str("c"), NewStyle().Str("1"))
+	table.AddRow(NewStyle().Str("a"), NewStyle().Str("3"))
+	names := func() (names []string) {
+		for _, row := range table.Rows() {
+			names = append(names, row[0].Str)
+		}
+		return names
+	}
+	table.SortBy(0, false)
+	if got := names(); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("sorted by name = %v", got)
+	}
+	table.SortBy(1, true)
+	if got := names(); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("sorted by size descending = %v", got)
+	}
+	table.SortBy(-1, false)
+	if got := names(); !reflect.DeepEqual(got, []string{"b", "c", "a"}) {
+		t.Errorf("unsorted = %v", got)
+	}
+	if got := truncate("abcdef", 4); got != "abc"+string(glyphs().ellipsis) {
+		t.Errorf("truncate = %q", got)
+	}
+}
+
+func TestTableSelectCanChangeTable(t *testing.T) {
+	table := NewTable(TableColumn{Title: "name"})
+	table.AddRow(NewStyle().Str("a"))
+	table.OnSelect(func(row []ColorStr) {
+		table.AddRow(NewStyle().Str(row[0].Str + "+"))
+	})
+	table.HandleEvent(termbox.Event{Type: termbox.EventKey, Key: termbox.KeyEnter})
+	if rows := table.Rows(); len(rows) != 2 || rows[1][0].Str != "a+" {
+		t.Errorf("rows = %v", rows)
+	}
+}
+
+func TestColumnFitIsDefault(t *testing.T) {
+	table := NewTable(TableColumn{Title: "name"}, TableColumn{Title: "size", Width: 2})
+	table.AddRow(NewStyle().Str("longer name"), NewStyle().Str("12345"))
+	if got, want := table.columnWidths(40), []int{11, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("columnWidths(40) = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,256 @@
+package gopanes
+
+import (
+	"github.com/nsf/termbox-go"
+	"os"
+	"os/exec"
+	"sync"
+	"unicode/utf8"
+)
+
+// Terminal is a widget that runs a program on a pseudo-terminal and shows its
+// screen, so interactive programs like shells and editors can live in a pane.
+// Keys go to the program while the pane is focused, except the prefix key.
+// Only Linux is supported.
+type Terminal struct {
+	lock      sync.Mutex
+	screen    *vtScreen
+	pty       *os.File
+	cmd       *exec.Cmd
+	isFocused bool
+	exited    chan struct{}
+	err       error // from cmd.Wait, set before exited is closed
+	closeOnce sync.Once
+	replies   [][]byte // answers to the program's queries, written once the lock is released
+}
+
+// RunTerminal starts cmd on a pseudo-terminal the size of the pane and puts a
+// Terminal showing it in the pane. The program is stopped when the pane is
+// closed.
+func (gp *GoPane) RunTerminal(cmd *exec.Cmd) (*Terminal, error) {
+	_, _, width, height := gp.contentArea()
+	t, err := startTerminal(cmd, width, height, gp.Refresh)
+	if err != nil {
+		return nil, err
+	}
+	gp.SetWidget(t)
+	go func() {
+		select {
+		case <-gp.Done():
+			t.Close()
+		case <-t.exited:
+		}
+	}()
+	return t, nil
+}
+
+// startTerminal starts cmd on a new pseudo-terminal, calling refresh whenever
+// its screen changes
+func startTerminal(cmd *exec.Cmd, width, height int, refresh func()) (*Terminal, error) {
+	if width < 1 || height < 1 {
+		width, height = 80, 24
+	}
+	master, slave, err := openPty()
+	if err != nil {
+		return nil, err
+	}
+	if err := setPtySize(master, width, height); err != nil {
+		master.Close()
+		slave.Close()
+		return nil, err
+	}
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	// the last TERM wins
+	cmd.Env = append(cmd.Env, "TERM=xterm-256color")
+	err = startOnPty(cmd, slave)
+	// only the program needs the slave end
+	slave.Close()
+	if err != nil {
+		master.Close()
+		return nil, err
+	}
+	t := &Terminal{
+		screen: newVtScreen(width, height),
+		pty:    master,
+		cmd:    cmd,
+		exited: make(chan struct{}),
+	}
+	// the program may not be reading its input, so the answers aren't
+	// written while the screen is locked
+	t.screen.reply = func(answer []byte) { t.replies = append(t.replies, answer) }
+	go t.read(refresh)
+	return t, nil
+}
+
+// read feeds the program's output to the screen until it exits
+func (t *Terminal) read(refresh func()) {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := t.pty.Read(buf)
+		if n > 0 {
+			t.lock.Lock()
+			t.screen.Write(buf[:n])
+			replies := t.replies
+			t.replies = nil
+			t.lock.Unlock()
+			for _, answer := range replies {
+				t.pty.Write(answer)
+			}
+			refresh()
+		}
+		// reading fails once the program and its children are gone
+		if err != nil {
+			break
+		}
+	}
+	t.err = t.cmd.Wait()
+	t.Close()
+	close(t.exited)
+	refresh()
+}
+
+// Close stops the program: closing the pty hangs it up, and it's killed along
+// with the processes it started in case they ignore that
+func (t *Terminal) Close() {
+	t.closeOnce.Do(func() {
+		t.pty.Close()
+		// the program leads a session and process group of its own
+		killProcessGroup(t.cmd)
+	})
+}
+
+// Wait waits for the program to exit, returning the error from exec.Cmd.Wait
+func (t *Terminal) Wait() error {
+	<-t.exited
+	return t.err
+}
+
+// Done returns a channel that's closed when the program has exited
+func (t *Terminal) Done() <-chan struct{} {
+	return t.exited
+}
+
+// Title returns the title the program set with an OSC escape sequence
+func (t *Terminal) Title() string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.screen.title
+}
+
+// running tells whether the program is still running
+func (t *Terminal) running() bool {
+	select {
+	case <-t.exited:
+		return false
+	default:
+		return true
+	}
+}
+
+func (t *Terminal) Focus() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.isFocused = true
+}
+
+func (t *Terminal) Blur() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.isFocused = false
+}
+
+// PreferredSize is the size of the program's screen
+func (t *Terminal) PreferredSize() (width, height int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.screen.width, t.screen.height
+}
+
+// HandleEvent passes keys on to the program
+func (t *Terminal) HandleEvent(ev termbox.Event) {
+	if ev.Type != termbox.EventKey || !t.running() {
+		return
+	}
+	t.lock.Lock()
+	input := terminalKey(ev, t.screen.appCursor)
+	t.lock.Unlock()
+	if len(input) > 0 {
+		t.pty.Write(input)
+	}
+}
+
+func (t *Terminal) Draw(c *Canvas) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	width, height := c.Width(), c.Height()
+	if width < 1 || height < 1 {
+		return
+	}
+	if width != t.screen.width || height != t.screen.height {
+		t.screen.resize(width, height)
+		if t.running() {
+			// the program redraws itself when it gets SIGWINCH
+			setPtySize(t.pty, width, height)
+		}
+	}
+	t.screen.draw(c)
+	if t.isFocused && !t.screen.cursorHidden && t.running() {
+		c.SetCursor(t.screen.x, t.screen.y)
+	}
+}
+
+// the escape sequences xterm sends for the function keys
+var terminalFunctionKeys = map[termbox.Key]string{
+	termbox.KeyF1:     "\x1bOP",
+	termbox.KeyF2:     "\x1bOQ",
+	termbox.KeyF3:     "\x1bOR",
+	termbox.KeyF4:     "\x1bOS",
+	termbox.KeyF5:     "\x1b[15~",
+	termbox.KeyF6:     "\x1b[17~",
+	termbox.KeyF7:     "\x1b[18~",
+	termbox.KeyF8:     "\x1b[19~",
+	termbox.KeyF9:     "\x1b[20~",
+	termbox.KeyF10:    "\x1b[21~",
+	termbox.KeyF11:    "\x1b[23~",
+	termbox.KeyF12:    "\x1b[24~",
+	termbox.KeyInsert: "\x1b[2~",
+	termbox.KeyDelete: "\x1b[3~",
+	termbox.KeyPgup:   "\x1b[5~",
+	termbox.KeyPgdn:   "\x1b[6~",
+}
+
+// the final bytes of the cursor key sequences
+var terminalCursorKeys = map[termbox.Key]byte{
+	termbox.KeyArrowUp:    'A',
+	termbox.KeyArrowDown:  'B',
+	termbox.KeyArrowRight: 'C',
+	termbox.KeyArrowLeft:  'D',
+	termbox.KeyHome:       'H',
+	termbox.KeyEnd:        'F',
+}
+
+// terminalKey returns the bytes a terminal sends a program for a key. The
+// cursor keys send ESC O instead of ESC [ sequences in application mode.
+func terminalKey(ev termbox.Event, appCursor bool) []byte {
+	var input []byte
+	if ev.Ch != 0 {
+		input = []byte(string(ev.Ch))
+	} else if final, ok := terminalCursorKeys[ev.Key]; ok {
+		if appCursor {
+			input = []byte{0x1b, 'O', final}
+		} else {
+			input = []byte{0x1b, '[', final}
+		}
+	} else if seq, ok := terminalFunctionKeys[ev.Key]; ok {
+		input = []byte(seq)
+	} else if ev.Key < utf8.RuneSelf {
+		// termbox's other keys are the control characters they send
+		input = []byte{byte(ev.Key)}
+	}
+	if ev.Mod&termbox.ModAlt != 0 && len(input) > 0 {
+		input = append([]byte{0x1b}, input...)
+	}
+	return input
+}
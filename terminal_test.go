@@ -0,0 +1,99 @@
+package gopanes
+
+import (
+	"github.com/nsf/termbox-go"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTerminalKey(t *testing.T) {
+	tests := []struct {
+		ev        termbox.Event
+		appCursor bool
+		want      string
+	}{
+		{termbox.Event{Ch: 'é'}, false, "é"},
+		{termbox.Event{Ch: 'x', Mod: termbox.ModAlt}, false, "\x1bx"},
+		{termbox.Event{Key: termbox.KeyEnter}, false, "\r"},
+		{termbox.Event{Key: termbox.KeyCtrlC}, false, "\x03"},
+		{termbox.Event{Key: termbox.KeyBackspace2}, false, "\x7f"},
+		{termbox.Event{Key: termbox.KeyArrowUp}, false, "\x1b[A"},
+		{termbox.Event{Key: termbox.KeyArrowUp}, true, "\x1bOA"},
+		{termbox.Event{Key: termbox.KeyF5}, false, "\x1b[15~"},
+		{termbox.Event{Key: termbox.KeyDelete}, false, "\x1b[3~"},
+	}
+	for _, test := range tests {
+		if got := string(terminalKey(test.ev, test.appCursor)); got != test.want {
+			t.Errorf("terminalKey(%+v, %v) = %q, want %q", test.ev, test.appCursor, got, test.want)
+		}
+	}
+}
+
+func TestTerminalRunsOnPty(t *testing.T) {
+	cmd := exec.Command("sh", "-c", `stty size; [ -t 0 ] && printf 'tty\033[31mred'`)
+	term, err := startTerminal(cmd, 30, 5, func() {})
+	if err != nil {
+		t.Skipf("no pseudo-terminals: %v", err)
+	}
+	select {
+	case <-term.Done():
+	case <-time.After(5 * time.Second):
+		term.Close()
+		t.Fatal("the program didn't exit")
+	}
+	if err := term.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	rows := screenText(term.screen)
+	if got := strings.Join(rows[:2], "|"); got != "5 30|ttyred" {
+		t.Errorf("screen = %q", got)
+	}
+	if style := term.screen.cells[1][3].Style; style != NewStyle().Fg(ColorRed) {
+		t.Errorf("style = %v, want red", style)
+	}
+}
+
+// processGone tells whether a process has exited, counting zombies as gone
+func processGone(pid string) bool {
+	stat, err := os.ReadFile("/proc/" + pid + "/stat")
+	return err != nil || strings.Contains(string(stat), ") Z")
+}
+
+func TestTerminalCloseKillsChildren(t *testing.T) {
+	// the background sleep ignores the hangup closing the pty sends
+	cmd := exec.Command("sh", "-c", `trap "" HUP; sleep 30 & echo "pid $!"; wait`)
+	term, err := startTerminal(cmd, 30, 5, func() {})
+	if err != nil {
+		t.Skipf("no pseudo-terminals: %v", err)
+	}
+	var pid string
+	for deadline := time.Now().Add(5 * time.Second); pid == "" && time.Now().Before(deadline); {
+		term.lock.Lock()
+		row := screenText(term.screen)[0]
+		term.lock.Unlock()
+		if strings.HasPrefix(row, "pid ") {
+			pid = strings.TrimSpace(strings.TrimPrefix(row, "pid "))
+		} else {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	if pid == "" {
+		term.Close()
+		t.Fatal("the program didn't start its child")
+	}
+	term.Close()
+	select {
+	case <-term.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("the program didn't exit")
+	}
+	for deadline := time.Now().Add(5 * time.Second); !processGone(pid); {
+		if time.Now().After(deadline) {
+			t.Fatalf("child %s outlived the terminal", pid)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,216 @@
+package gopanes
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"unicode/utf8"
+)
+
+// Theme controls how the parts of the UI that aren't pane content are drawn
+type Theme struct {
+	VerticalDivider   rune
+	HorizontalDivider rune
+	// junctions, named after the direction the extra arm points
+	Cross    rune // ┼
+	TeeDown  rune // ┬
+	TeeUp    rune // ┴
+	TeeRight rune // ├
+	TeeLeft  rune // ┤
+
+	DividerStyle Style
+	// dividers touching the focused pane are drawn with this style instead
+	FocusedDividerStyle Style
+	// draws the content of unfocused panes with the dim attribute
+	DimUnfocused bool
+
+	// the header rows of panes with a title (see GoPane.SetTitle)
+	TitleStyle        Style
+	FocusedTitleStyle Style
+}
+
+// defaultTheme is what panes are drawn with when the UI has no theme. It's
+// shared, so it's never changed, only replaced when the glyphs change.
+func defaultTheme() *Theme {
+	glyphsLock.RLock()
+	defer glyphsLock.RUnlock()
+	return currentDefaultTheme
+}
+
+// DefaultTheme returns the default theme, which uses ASCII glyphs if the UI is
+// ASCII only (see GoPaneUi.SetASCIIOnly)
+func DefaultTheme() *Theme {
+	return newDefaultTheme(glyphs())
+}
+
+// newDefaultTheme returns the default theme drawn with the given glyphs
+func newDefaultTheme(g *glyphSet) *Theme {
+	return &Theme{
+		VerticalDivider:     g.vertical,
+		HorizontalDivider:   g.horizontal,
+		Cross:               g.cross,
+		TeeDown:             g.teeDown,
+		TeeUp:               g.teeUp,
+		TeeRight:            g.teeRight,
+		TeeLeft:             g.teeLeft,
+		DividerStyle:        NewStyle().Fg(ColorWhite),
+		FocusedDividerStyle: NewStyle().Fg(ColorGreen),
+		TitleStyle:          NewStyle().Reverse(),
+		FocusedTitleStyle:   NewStyle().Fg(ColorBlack).Bg(ColorGreen),
+	}
+}
+
+// the directions a divider cell connects in
+const (
+	dirUp uint8 = 1 << iota
+	dirDown
+	dirLeft
+	dirRight
+)
+
+// dividerGlyph picks the glyph for a divider cell from the directions it
+// connects in
+func (t *Theme) dividerGlyph(dirs uint8) rune {
+	vertical := dirUp | dirDown
+	horizontal := dirLeft | dirRight
+	switch {
+	case dirs&vertical == vertical && dirs&horizontal == horizontal:
+		return t.Cross
+	case dirs&vertical == vertical && dirs&dirRight != 0:
+		return t.TeeRight
+	case dirs&vertical == vertical && dirs&dirLeft != 0:
+		return t.TeeLeft
+	case dirs&horizontal == horizontal && dirs&dirDown != 0:
+		return t.TeeDown
+	case dirs&horizontal == horizontal && dirs&dirUp != 0:
+		return t.TeeUp
+	case dirs&vertical != 0:
+		return t.VerticalDivider
+	}
+	return t.HorizontalDivider
+}
+
+// themeStyle is the JSON form of a Style, with colors as in ParseColor
+type themeStyle struct {
+	Fg        string `json:"fg"`
+	Bg        string `json:"bg"`
+	Bold      bool   `json:"bold"`
+	Underline bool   `json:"underline"`
+	Reverse   bool   `json:"reverse"`
+	Blink     bool   `json:"blink"`
+}
+
+func (ts *themeStyle) style() (Style, error) {
+	style := NewStyle()
+	if ts.Fg != "" {
+		fg, err := ParseColor(ts.Fg)
+		if err != nil {
+			return style, err
+		}
+		style = style.Fg(fg)
+	}
+	if ts.Bg != "" {
+		bg, err := ParseColor(ts.Bg)
+		if err != nil {
+			return style, err
+		}
+		style = style.Bg(bg)
+	}
+	if ts.Bold {
+		style = style.Bold()
+	}
+	if ts.Underline {
+		style = style.Underline()
+	}
+	if ts.Reverse {
+		style = style.Reverse()
+	}
+	if ts.Blink {
+		style = style.Blink()
+	}
+	return style, nil
+}
+
+// themeFile is the JSON form of a Theme. Everything is optional, missing
+// values are taken from the default theme.
+type themeFile struct {
+	VerticalDivider     string      `json:"vertical_divider"`
+	HorizontalDivider   string      `json:"horizontal_divider"`
+	Cross               string      `json:"cross"`
+	TeeDown             string      `json:"tee_down"`
+	TeeUp               string      `json:"tee_up"`
+	TeeRight            string      `json:"tee_right"`
+	TeeLeft             string      `json:"tee_left"`
+	DividerStyle        *themeStyle `json:"divider"`
+	FocusedDividerStyle *themeStyle `json:"focused_divider"`
+	DimUnfocused        bool        `json:"dim_unfocused"`
+	TitleStyle          *themeStyle `json:"title"`
+	FocusedTitleStyle   *themeStyle `json:"focused_title"`
+}
+
+// ParseTheme reads a theme from JSON, for example
+//
+//	{
+//	    "vertical_divider": "┃",
+//	    "divider": {"fg": "darkgray"},
+//	    "focused_divider": {"fg": "#ffaf00", "bold": true},
+//	    "dim_unfocused": true
+//	}
+func ParseTheme(data []byte) (*Theme, error) {
+	var file themeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	theme := DefaultTheme()
+	glyphFields := []struct {
+		value string
+		dest  *rune
+	}{
+		{file.VerticalDivider, &theme.VerticalDivider},
+		{file.HorizontalDivider, &theme.HorizontalDivider},
+		{file.Cross, &theme.Cross},
+		{file.TeeDown, &theme.TeeDown},
+		{file.TeeUp, &theme.TeeUp},
+		{file.TeeRight, &theme.TeeRight},
+		{file.TeeLeft, &theme.TeeLeft},
+	}
+	for _, field := range glyphFields {
+		if field.value == "" {
+			continue
+		}
+		if utf8.RuneCountInString(field.value) != 1 {
+			return nil, fmt.Errorf("theme glyph %q is not a single character", field.value)
+		}
+		*field.dest, _ = utf8.DecodeRuneInString(field.value)
+	}
+	styleFields := []struct {
+		value *themeStyle
+		dest  *Style
+	}{
+		{file.DividerStyle, &theme.DividerStyle},
+		{file.FocusedDividerStyle, &theme.FocusedDividerStyle},
+		{file.TitleStyle, &theme.TitleStyle},
+		{file.FocusedTitleStyle, &theme.FocusedTitleStyle},
+	}
+	for _, field := range styleFields {
+		if field.value == nil {
+			continue
+		}
+		style, err := field.value.style()
+		if err != nil {
+			return nil, err
+		}
+		*field.dest = style
+	}
+	theme.DimUnfocused = file.DimUnfocused
+	return theme, nil
+}
+
+// LoadTheme reads a JSON theme file, see ParseTheme
+func LoadTheme(path string) (*Theme, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTheme(data)
+}
@@ -0,0 +1,109 @@
+package gopanes
+
+import (
+	"testing"
+)
+
+func TestParseTheme(t *testing.T) {
+	theme, err := ParseTheme([]byte(`{
+		"vertical_divider": "┃",
+		"divider": {"fg": "darkgray"},
+		"focused_title": {"fg": "#ffaf00", "bold": true},
+		"dim_unfocused": true
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	def := DefaultTheme()
+	if theme.VerticalDivider != '┃' || theme.HorizontalDivider != def.HorizontalDivider {
+		t.Errorf("dividers = %q and %q", theme.VerticalDivider, theme.HorizontalDivider)
+	}
+	if theme.DividerStyle != NewStyle().Fg(ColorDarkGray) || theme.FocusedTitleStyle != NewStyle().Fg(ColorRGB(0xff, 0xaf, 0)).Bold() {
+		t.Errorf("styles = %v and %v", theme.DividerStyle, theme.FocusedTitleStyle)
+	}
+	if !theme.DimUnfocused || theme.TitleStyle != def.TitleStyle {
+		t.Errorf("missing values weren't taken from the default theme: %+v", theme)
+	}
+
+	for _, bad := range []string{
+		`{"cross": "++"}`,
+		`{"divider": {"fg": "nocolor"}}`,
+		`{"divider": "white"}`,
+		`not json`,
+	} {
+		if _, err := ParseTheme([]byte(bad)); err == nil {
+			t.Errorf("ParseTheme(%s) didn't fail", bad)
+		}
+	}
+}
+
+func TestDividerGlyph(t *testing.T) {
+	theme := DefaultTheme()
+	tests := []struct {
+		dirs uint8
+		want rune
+	}{
+		{dirUp | dirDown, theme.VerticalDivider},
+		{dirUp, theme.VerticalDivider},
+		{dirLeft | dirRight, theme.HorizontalDivider},
+		{dirRight, theme.HorizontalDivider},
+		{dirUp | dirDown | dirLeft | dirRight, theme.Cross},
+		{dirUp | dirDown | dirRight, theme.TeeRight},
+		{dirUp | dirDown | dirLeft, theme.TeeLeft},
+		{dirLeft | dirRight | dirDown, theme.TeeDown},
+		{dirLeft | dirRight | dirUp, theme.TeeUp},
+	}
+	for _, test := range tests {
+		if got := theme.dividerGlyph(test.dirs); got != test.want {
+			t.Errorf("dividerGlyph(%04b) = %q, want %q", test.dirs, got, test.want)
+		}
+	}
+}
+
+func TestDividerJunctions(t *testing.T) {
+	ui, ms := newTestUi(t, 5, 5)
+	theme := DefaultTheme()
+	ui.Root.Vert(2)
+	ui.Root.Second.Horiz(2)
+	ui.Refresh()
+	if got := []rune(screenRows(ms)[2])[2]; got != theme.TeeRight {
+		t.Errorf("a divider ending on the right of another is %q, want %q", got, theme.TeeRight)
+	}
+	ui.Root.First.Horiz(2)
+	ui.Refresh()
+	rows := screenRows(ms)
+	if got := []rune(rows[2])[2]; got != theme.Cross {
+		t.Errorf("dividers meeting from both sides are %q, want %q", got, theme.Cross)
+	}
+	if got := []rune(rows[0])[2]; got != theme.VerticalDivider {
+		t.Errorf("the vertical divider is %q, want %q", got, theme.VerticalDivider)
+	}
+}
+
+func TestDefaultThemeFollowsGlyphs(t *testing.T) {
+	ui, _ := newTestUi(t, 5, 5)
+	ui.SetASCIIOnly(true)
+	defer ui.SetASCIIOnly(false)
+	if got := ui.Root.theme().VerticalDivider; got != '|' {
+		t.Errorf("ASCII only vertical divider = %q", got)
+	}
+}
+
+func TestSetASCIIOnlyWhileDrawing(t *testing.T) {
+	ui, _ := newTestUi(t, 20, 5)
+	ui.Root.Vert(10)
+	ui.Root.First.AddLine([]ColorStr{Color.Default("a line too long for the pane")})
+	defer ui.SetASCIIOnly(false)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			ui.SetASCIIOnly(i%2 == 0)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		ui.Refresh()
+		DefaultTheme()
+	}
+	<-done
+}
@@ -0,0 +1,73 @@
+package gopanes
+
+import (
+	"github.com/mattn/go-runewidth"
+)
+
+// SetTitle gives the pane a header row showing the title, the scroll position
+// and the status text (see SetTitleStatus). An empty title removes the header
+// row again.
+func (gp *GoPane) SetTitle(title string) {
+	if gp.isSplit() {
+		gp.First.SetTitle(title)
+		return
+	}
+	gp.title = title
+}
+
+func (gp *GoPane) Title() string {
+	return gp.title
+}
+
+// SetTitleStatus sets text shown at the right end of the pane's header row
+func (gp *GoPane) SetTitleStatus(status string) {
+	if gp.isSplit() {
+		gp.First.SetTitleStatus(status)
+		return
+	}
+	gp.titleStatus = status
+}
+
+// contentArea returns the part of the pane left for content once the header
+// row is taken out
+func (gp *GoPane) contentArea() (x, y, width, height int) {
+	if gp.title == "" || gp.height < 1 {
+		return gp.x, gp.y, gp.width, gp.height
+	}
+	return gp.x, gp.y + 1, gp.width, gp.height - 1
+}
+
+// scrollPosition asks the pane's widget where it's scrolled to, if it can say
+func (gp *GoPane) scrollPosition() string {
+	if scroller, ok := gp.widget.(interface {
+		ScrollPosition() string
+	}); ok {
+		return scroller.ScrollPosition()
+	}
+	return ""
+}
+
+func (gp *GoPane) drawTitle() {
+	theme := gp.theme()
+	style := theme.TitleStyle
+	if gp.isFocused {
+		style = theme.FocusedTitleStyle
+	}
+
+	right := gp.titleStatus
+	if position := gp.scrollPosition(); position != "" {
+		if right != "" {
+			right += " "
+		}
+		right += position
+	}
+	right = runewidth.Truncate(right, gp.width, "")
+	rightWidth := runewidth.StringWidth(right)
+	// keep a space between the title and the right hand side
+	left := runewidth.Truncate(" "+gp.title, gp.width-rightWidth-1, "")
+
+	c := NewCanvas(gp.x, gp.y, gp.width, 1)
+	c.Clear(style)
+	c.Print(0, 0, left, style)
+	c.Print(gp.width-rightWidth, 0, right, style)
+}
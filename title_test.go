@@ -0,0 +1,48 @@
+package gopanes
+
+import (
+	"testing"
+)
+
+func TestContentArea(t *testing.T) {
+	tests := []struct {
+		title  string
+		height int
+		want   [4]int
+	}{
+		{"", 5, [4]int{1, 2, 10, 5}},
+		{"title", 5, [4]int{1, 3, 10, 4}},
+		{"title", 1, [4]int{1, 3, 10, 0}},
+		{"title", 0, [4]int{1, 2, 10, 0}},
+	}
+	for _, test := range tests {
+		gp := NewGoPane(10, test.height, 1, 2)
+		gp.SetTitle(test.title)
+		x, y, width, height := gp.contentArea()
+		if got := [4]int{x, y, width, height}; got != test.want {
+			t.Errorf("%q at height %d: contentArea() = %v, want %v", test.title, test.height, got, test.want)
+		}
+	}
+}
+
+func TestTitleStatus(t *testing.T) {
+	ui, ms := newTestUi(t, 12, 2)
+	ui.Root.SetWidget(&recordingWidget{})
+	ui.Root.SetTitle("logs")
+	tests := []struct {
+		status string
+		want   string
+	}{
+		{"", " logs       "},
+		{"running", " log running"},
+		// the status wins over the title, and is cut off on the right
+		{"0123456789abcdef", "0123456789ab"},
+	}
+	for _, test := range tests {
+		ui.Root.SetTitleStatus(test.status)
+		ui.Refresh()
+		if got := screenRows(ms)[0]; got != test.want {
+			t.Errorf("with status %q, title row = %q, want %q", test.status, got, test.want)
+		}
+	}
+}
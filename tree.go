@@ -0,0 +1,513 @@
+package gopanes
+
+import (
+	"fmt"
+	"github.com/nsf/termbox-go"
+	"strings"
+	"sync"
+	"time"
+)
+
+type TreeNode struct {
+	Text  []ColorStr
+	Value interface{} // anything the program wants to associate with the node
+	// marks a node whose children haven't been loaded yet, see
+	// Tree.SetLoader
+	Expandable bool
+
+	children []*TreeNode
+	parent   *TreeNode
+	expanded bool
+	loaded   bool
+}
+
+func NewTreeNode(text []ColorStr, value interface{}) *TreeNode {
+	return &TreeNode{Text: text, Value: value}
+}
+
+// AddChild appends a child to the node and returns it
+func (tn *TreeNode) AddChild(child *TreeNode) *TreeNode {
+	child.parent = tn
+	tn.children = append(tn.children, child)
+	return child
+}
+
+func (tn *TreeNode) Children() []*TreeNode {
+	return tn.children
+}
+
+// Parent returns the node's parent, or nil for a root node
+func (tn *TreeNode) Parent() *TreeNode {
+	return tn.parent
+}
+
+func (tn *TreeNode) IsExpanded() bool {
+	return tn.expanded
+}
+
+func (tn *TreeNode) hasChildren() bool {
+	return len(tn.children) > 0 || (tn.Expandable && !tn.loaded)
+}
+
+// plainText returns the node's text without styles
+func (tn *TreeNode) plainText() string {
+	return lineText(tn.Text)
+}
+
+// treeRow is a node as it's shown, with the guides leading up to it
+type treeRow struct {
+	node   *TreeNode
+	guides string
+}
+
+// Tree is a widget showing hierarchical data as nodes that can be expanded
+// and collapsed. The arrow keys, j and k, PgUp/PgDn and Home/End move the
+// cursor, right expands the node under it or moves to its first child, left
+// collapses it or moves to its parent, Space toggles it, typing other
+// characters jumps to the next visible node starting with them, and Enter
+// selects the node. Clicking a node's marker toggles it and clicking the
+// node under the cursor selects it.
+type Tree struct {
+	roots     []*TreeNode
+	nodesLock sync.Mutex
+	rows      []treeRow // the visible nodes, rebuilt when the tree changes
+	cursor    int       // index into rows
+	offset    int       // first visible row
+	height    int       // the height the tree was last drawn at
+	isFocused bool
+	jumpText  string
+	lastJump  time.Time
+	loader    func(node *TreeNode) []*TreeNode
+	onSelect  func(node *TreeNode)
+
+	CursorStyle Style
+	GuideStyle  Style
+}
+
+func NewTree(roots ...*TreeNode) *Tree {
+	t := &Tree{
+		CursorStyle: NewStyle().Reverse(),
+		GuideStyle:  NewStyle().Fg(ColorDarkGray),
+	}
+	t.SetRoots(roots)
+	return t
+}
+
+// SetRoots replaces the whole tree, moving the cursor back to the top
+func (t *Tree) SetRoots(roots []*TreeNode) {
+	t.nodesLock.Lock()
+	defer t.nodesLock.Unlock()
+	t.roots = roots
+	t.cursor, t.offset = 0, 0
+	t.buildRows()
+}
+
+// AddRoot appends a root node and returns it
+func (t *Tree) AddRoot(node *TreeNode) *TreeNode {
+	t.nodesLock.Lock()
+	defer t.nodesLock.Unlock()
+	t.roots = append(t.roots, node)
+	t.buildRows()
+	return node
+}
+
+func (t *Tree) Roots() []*TreeNode {
+	t.nodesLock.Lock()
+	defer t.nodesLock.Unlock()
+	return append([]*TreeNode(nil), t.roots...)
+}
+
+// Update rebuilds the visible rows after nodes have been added or removed
+// outside of the tree's methods
+func (t *Tree) Update() {
+	t.nodesLock.Lock()
+	defer t.nodesLock.Unlock()
+	t.buildRows()
+}
+
+// SetLoader sets a function that loads the children of Expandable nodes the
+// first time they're expanded. It's called from the UI's event loop, so it
+// shouldn't take long.
+func (t *Tree) SetLoader(loader func(node *TreeNode) []*TreeNode) {
+	t.loader = loader
+}
+
+// OnSelect sets a function called with the selected node when the user makes
+// a selection. It's called from the UI's event loop, so it shouldn't block.
+func (t *Tree) OnSelect(onSelect func(node *TreeNode)) {
+	t.onSelect = onSelect
+}
+
+// Selected returns the node under the cursor, or nil if the tree is empty
+func (t *Tree) Selected() *TreeNode {
+	t.nodesLock.Lock()
+	defer t.nodesLock.Unlock()
+	return t.cursorNode()
+}
+
+// Expand shows the node's children, loading them first if needed
+func (t *Tree) Expand(node *TreeNode) {
+	t.load(node)
+	t.nodesLock.Lock()
+	defer t.nodesLock.Unlock()
+	t.expand(node)
+	t.buildRows()
+}
+
+// Collapse hides the node's children
+func (t *Tree) Collapse(node *TreeNode) {
+	t.nodesLock.Lock()
+	defer t.nodesLock.Unlock()
+	node.expanded = false
+	t.buildRows()
+}
+
+// Find moves the cursor to the first node, in display order, that match
+// accepts, expanding its ancestors so it's visible. Only loaded nodes are
+// searched. It returns false if no node matched.
+func (t *Tree) Find(match func(node *TreeNode) bool) bool {
+	t.nodesLock.Lock()
+	defer t.nodesLock.Unlock()
+	var found *TreeNode
+	var search func(nodes []*TreeNode)
+	search = func(nodes []*TreeNode) {
+		for _, node := range nodes {
+			if found != nil {
+				return
+			}
+			if match(node) {
+				found = node
+				return
+			}
+			search(node.children)
+		}
+	}
+	search(t.roots)
+	if found == nil {
+		return false
+	}
+	for ancestor := found.parent; ancestor != nil; ancestor = ancestor.parent {
+		ancestor.expanded = true
+	}
+	t.buildRows()
+	t.moveTo(found)
+	return true
+}
+
+// needsLoading reports whether the node's children have to be loaded before
+// it's expanded. The caller must hold nodesLock.
+func (t *Tree) needsLoading(node *TreeNode) bool {
+	return node.Expandable && !node.loaded && t.loader != nil
+}
+
+// load loads the node's children if they haven't been. The loader may be slow
+// or use the tree, so it's called without nodesLock, which the caller must
+// not hold.
+func (t *Tree) load(node *TreeNode) {
+	t.nodesLock.Lock()
+	needed := t.needsLoading(node)
+	t.nodesLock.Unlock()
+	if !needed {
+		return
+	}
+	children := t.loader(node)
+	t.nodesLock.Lock()
+	defer t.nodesLock.Unlock()
+	// it may have been loaded in the meantime
+	if !node.loaded {
+		for _, child := range children {
+			node.AddChild(child)
+		}
+		node.loaded = true
+	}
+}
+
+// expand shows the node's children, which must have been loaded. The caller
+// must hold nodesLock and rebuild the rows.
+func (t *Tree) expand(node *TreeNode) {
+	node.loaded = true
+	node.expanded = true
+}
+
+// buildRows works out the visible rows, keeping the cursor on the same node
+// if it's still visible. The caller must hold nodesLock.
+func (t *Tree) buildRows() {
+	var current *TreeNode
+	if t.cursor < len(t.rows) {
+		current = t.rows[t.cursor].node
+	}
+	t.rows = t.rows[:0]
+	var add func(nodes []*TreeNode, prefix string)
+	add = func(nodes []*TreeNode, prefix string) {
+		for i, node := range nodes {
+			last := i == len(nodes)-1
+			connector, continuation := string(glyphs().teeRight), string(glyphs().vertical)
+			if last {
+				connector, continuation = string(glyphs().bottomLeft), " "
+			}
+			guides := prefix + connector + string(glyphs().horizontal)
+			// roots don't get guides
+			if node.parent == nil {
+				guides, continuation = "", ""
+			}
+			t.rows = append(t.rows, treeRow{node: node, guides: guides})
+			if node.expanded {
+				childPrefix := prefix + continuation + " "
+				if node.parent == nil {
+					childPrefix = ""
+				}
+				add(node.children, childPrefix)
+			}
+		}
+	}
+	add(t.roots, "")
+	if current != nil {
+		t.moveTo(current)
+	}
+	t.moveCursor(t.cursor)
+}
+
+// moveTo moves the cursor to the node's row, or to its nearest visible
+// ancestor. The caller must hold nodesLock.
+func (t *Tree) moveTo(node *TreeNode) {
+	for ; node != nil; node = node.parent {
+		for idx, row := range t.rows {
+			if row.node == node {
+				t.cursor = idx
+				return
+			}
+		}
+	}
+}
+
+// the caller must hold nodesLock
+func (t *Tree) moveCursor(idx int) {
+	if idx >= len(t.rows) {
+		idx = len(t.rows) - 1
+	}
+	if idx < 0 {
+		idx = 0
+	}
+	t.cursor = idx
+}
+
+// toggle expands or collapses the node in the given row. A node whose
+// children have to be loaded first is returned instead of being expanded. The
+// caller must hold nodesLock.
+func (t *Tree) toggle(idx int) (load *TreeNode) {
+	if idx < 0 || idx >= len(t.rows) {
+		return nil
+	}
+	node := t.rows[idx].node
+	if node.expanded {
+		node.expanded = false
+	} else if t.needsLoading(node) {
+		return node
+	} else if node.hasChildren() {
+		t.expand(node)
+	}
+	t.buildRows()
+	return nil
+}
+
+// cursorNode returns the node under the cursor, or nil if the tree is empty.
+// The caller must hold nodesLock.
+func (t *Tree) cursorNode() *TreeNode {
+	if t.cursor >= len(t.rows) {
+		return nil
+	}
+	return t.rows[t.cursor].node
+}
+
+// jumpTo moves the cursor to the next visible node starting with the typed
+// text. The caller must hold nodesLock.
+func (t *Tree) jumpTo(ch rune) {
+	now := time.Now()
+	if now.Sub(t.lastJump) > jumpTimeout {
+		t.jumpText = ""
+	}
+	t.lastJump = now
+	t.jumpText += strings.ToLower(string(ch))
+	// a fresh search starts after the cursor, a continued one includes it
+	start := t.cursor
+	if len([]rune(t.jumpText)) == 1 {
+		start++
+	}
+	for i := 0; i < len(t.rows); i++ {
+		idx := (start + i) % len(t.rows)
+		if strings.HasPrefix(strings.ToLower(t.rows[idx].node.plainText()), t.jumpText) {
+			t.cursor = idx
+			return
+		}
+	}
+}
+
+func (t *Tree) HandleEvent(ev termbox.Event) {
+	t.nodesLock.Lock()
+	selected, load := t.handleEvent(ev)
+	t.nodesLock.Unlock()
+	// the loader and callback may use the tree, so they're called without
+	// the lock
+	if load != nil {
+		t.Expand(load)
+	}
+	if selected != nil && t.onSelect != nil {
+		t.onSelect(selected)
+	}
+}
+
+// handleEvent handles an event, returning the node selected and the node to
+// load and expand, if any. The caller must hold nodesLock.
+func (t *Tree) handleEvent(ev termbox.Event) (selected, load *TreeNode) {
+	if ev.Type == termbox.EventMouse {
+		return t.handleMouse(ev)
+	}
+	switch ev.Key {
+	case termbox.KeyArrowUp:
+		t.moveCursor(t.cursor - 1)
+	case termbox.KeyArrowDown:
+		t.moveCursor(t.cursor + 1)
+	case termbox.KeyPgup:
+		t.moveCursor(t.cursor - t.height)
+	case termbox.KeyPgdn:
+		t.moveCursor(t.cursor + t.height)
+	case termbox.KeyHome:
+		t.moveCursor(0)
+	case termbox.KeyEnd:
+		t.moveCursor(len(t.rows) - 1)
+	case termbox.KeyArrowRight:
+		if t.cursor >= len(t.rows) {
+			return nil, nil
+		}
+		node := t.rows[t.cursor].node
+		if node.expanded {
+			if len(node.children) > 0 {
+				t.moveCursor(t.cursor + 1)
+			}
+		} else {
+			return nil, t.toggle(t.cursor)
+		}
+	case termbox.KeyArrowLeft:
+		if t.cursor >= len(t.rows) {
+			return nil, nil
+		}
+		node := t.rows[t.cursor].node
+		if node.expanded {
+			t.toggle(t.cursor)
+		} else if node.parent != nil {
+			t.moveTo(node.parent)
+		}
+	case termbox.KeySpace:
+		return nil, t.toggle(t.cursor)
+	case termbox.KeyEnter:
+		return t.cursorNode(), nil
+	default:
+		switch ev.Ch {
+		case 0:
+		case 'k':
+			t.moveCursor(t.cursor - 1)
+		case 'j':
+			t.moveCursor(t.cursor + 1)
+		default:
+			t.jumpTo(ev.Ch)
+		}
+	}
+	return nil, nil
+}
+
+// the caller must hold nodesLock
+func (t *Tree) handleMouse(ev termbox.Event) (selected, load *TreeNode) {
+	switch ev.Key {
+	case termbox.MouseWheelUp:
+		t.moveCursor(t.cursor - 3)
+	case termbox.MouseWheelDown:
+		t.moveCursor(t.cursor + 3)
+	case termbox.MouseLeft:
+		// clicks above the tree, on the pane's title, are ignored
+		if ev.MouseY < 0 {
+			return nil, nil
+		}
+		idx := t.offset + ev.MouseY
+		if idx >= len(t.rows) {
+			return nil, nil
+		}
+		markerX := len([]rune(t.rows[idx].guides))
+		switch {
+		case ev.MouseX == markerX:
+			t.cursor = idx
+			return nil, t.toggle(idx)
+		case idx == t.cursor:
+			// clicking the node under the cursor toggles it if it has
+			// children and selects it otherwise
+			if t.rows[idx].node.hasChildren() {
+				return nil, t.toggle(idx)
+			}
+			return t.cursorNode(), nil
+		default:
+			t.cursor = idx
+		}
+	}
+	return nil, nil
+}
+
+func (t *Tree) Focus() { t.isFocused = true }
+func (t *Tree) Blur()  { t.isFocused = false }
+
+// PreferredSize is the width of the widest visible node and the number of
+// visible nodes
+func (t *Tree) PreferredSize() (width, height int) {
+	t.nodesLock.Lock()
+	defer t.nodesLock.Unlock()
+	for _, row := range t.rows {
+		if w := len([]rune(row.guides)) + 2 + colorStrsWidth(row.node.Text); w > width {
+			width = w
+		}
+	}
+	return width, len(t.rows)
+}
+
+// ScrollPosition shows which visible node the cursor is on
+func (t *Tree) ScrollPosition() string {
+	t.nodesLock.Lock()
+	defer t.nodesLock.Unlock()
+	if len(t.rows) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("[%d/%d]", t.cursor+1, len(t.rows))
+}
+
+func (t *Tree) Draw(c *Canvas) {
+	t.nodesLock.Lock()
+	defer t.nodesLock.Unlock()
+	t.height = c.Height()
+	c.Clear(NewStyle())
+	t.moveCursor(t.cursor)
+	// scroll just enough to keep the cursor visible
+	if t.cursor < t.offset {
+		t.offset = t.cursor
+	}
+	if t.cursor >= t.offset+t.height {
+		t.offset = t.cursor - t.height + 1
+	}
+	for y := 0; y < t.height && t.offset+y < len(t.rows); y++ {
+		idx := t.offset + y
+		row := t.rows[idx]
+		x := c.Print(0, y, row.guides, t.GuideStyle)
+		marker := " "
+		if row.node.expanded {
+			marker = string(glyphs().expanded)
+		} else if row.node.hasChildren() {
+			marker = string(glyphs().collapsed)
+		}
+		x += c.Print(x, y, marker+" ", t.GuideStyle)
+		text := row.node.Text
+		if idx == t.cursor && t.isFocused {
+			c.Fill(x, y, c.Width()-x, 1, ' ', t.CursorStyle)
+			text = make([]ColorStr, len(row.node.Text))
+			for i, colorStr := range row.node.Text {
+				text[i] = inheritStyle(colorStr.Style, t.CursorStyle).Str(colorStr.Str)
+			}
+		}
+		c.PrintColorStrs(x, y, text)
+	}
+}
@@ -0,0 +1,90 @@
+package gopanes
+
+import (
+	"github.com/nsf/termbox-go"
+	"reflect"
+	"testing"
+)
+
+func TestTreeRows(t *testing.T) {
+	root := NewTreeNode([]ColorStr{NewStyle().Str("root")}, nil)
+	a := root.AddChild(NewTreeNode([]ColorStr{NewStyle().Str("a")}, nil))
+	a.AddChild(NewTreeNode([]ColorStr{NewStyle().Str("a1")}, nil))
+	lazy := root.AddChild(&TreeNode{Text: []ColorStr{NewStyle().Str("lazy")}, Expandable: true})
+	tree := NewTree(root)
+	loads := 0
+	tree.SetLoader(func(node *TreeNode) []*TreeNode {
+		loads++
+		return []*TreeNode{NewTreeNode([]ColorStr{NewStyle().Str("loaded")}, nil)}
+	})
+	tree.Expand(root)
+	tree.Expand(a)
+	tree.Expand(lazy)
+	tree.Collapse(lazy)
+	tree.Expand(lazy)
+	if loads != 1 {
+		t.Errorf("children loaded %d times, want once", loads)
+	}
+	rows := func() (rows []string) {
+		for _, row := range tree.rows {
+			rows = append(rows, row.guides+row.node.plainText())
+		}
+		return rows
+	}
+	g := glyphs()
+	want := []string{
+		"root",
+		string([]rune{g.teeRight, g.horizontal}) + "a",
+		string([]rune{g.vertical, ' ', g.bottomLeft, g.horizontal}) + "a1",
+		string([]rune{g.bottomLeft, g.horizontal}) + "lazy",
+		string([]rune{' ', ' ', g.bottomLeft, g.horizontal}) + "loaded",
+	}
+	if got := rows(); !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %q, want %q", got, want)
+	}
+	// collapsing a node moves the cursor off its hidden children
+	tree.Find(func(node *TreeNode) bool { return node.plainText() == "a1" })
+	if got := tree.Selected(); got == nil || got.plainText() != "a1" {
+		t.Fatalf("Find selected %v", got)
+	}
+	tree.Collapse(a)
+	if got := tree.Selected(); got != a {
+		t.Errorf("after collapsing, cursor is on %q", got.plainText())
+	}
+}
+
+func TestTreeCallbacksCanUseTree(t *testing.T) {
+	lazy := &TreeNode{Text: []ColorStr{NewStyle().Str("lazy")}, Expandable: true}
+	tree := NewTree(lazy)
+	tree.SetLoader(func(node *TreeNode) []*TreeNode {
+		// the loader can look at the tree while it runs
+		return []*TreeNode{NewTreeNode([]ColorStr{NewStyle().Str(tree.Selected().plainText() + " child")}, nil)}
+	})
+	var selected []string
+	tree.OnSelect(func(node *TreeNode) {
+		selected = append(selected, node.plainText())
+		tree.AddRoot(NewTreeNode([]ColorStr{NewStyle().Str("added")}, nil))
+	})
+	tree.HandleEvent(termbox.Event{Type: termbox.EventKey, Key: termbox.KeyArrowRight})
+	if children := lazy.Children(); len(children) != 1 || children[0].plainText() != "lazy child" {
+		t.Fatalf("children = %v", children)
+	}
+	tree.HandleEvent(termbox.Event{Type: termbox.EventKey, Key: termbox.KeyArrowRight})
+	tree.HandleEvent(termbox.Event{Type: termbox.EventKey, Key: termbox.KeyEnter})
+	if !reflect.DeepEqual(selected, []string{"lazy child"}) || len(tree.Roots()) != 2 {
+		t.Errorf("selected %q with roots %v", selected, tree.Roots())
+	}
+}
+
+func TestTreeIgnoresClicksAbove(t *testing.T) {
+	root := NewTreeNode([]ColorStr{NewStyle().Str("root")}, nil)
+	child := root.AddChild(NewTreeNode([]ColorStr{NewStyle().Str("child")}, nil))
+	tree := NewTree(root)
+	tree.Expand(root)
+	// scrolled down to the child, the row above it is the root
+	tree.offset, tree.cursor = 1, 1
+	tree.HandleEvent(termbox.Event{Type: termbox.EventMouse, Key: termbox.MouseLeft, MouseX: 0, MouseY: -1})
+	if got := tree.Selected(); got != child || !root.IsExpanded() {
+		t.Errorf("after a click on the title the cursor is on %q", got.plainText())
+	}
+}
@@ -0,0 +1,674 @@
+package gopanes
+
+import (
+	"fmt"
+	"github.com/mattn/go-runewidth"
+	"strings"
+	"unicode/utf8"
+)
+
+// the states of the escape sequence parser
+const (
+	vtGround       = iota
+	vtEscape       // after ESC
+	vtCharset      // after ESC ( or ESC ), waiting for the charset
+	vtEscapeIgnore // after an ESC sequence's intermediate byte
+	vtCSI          // in a control sequence, ESC [
+	vtString       // in an OSC, DCS or similar string, up to BEL or ST
+	vtStringEscape // after ESC in a string, which starts ST
+)
+
+// vtLineDrawing maps the DEC special graphics charset, selected with ESC ( 0,
+// to the box drawing runes
+var vtLineDrawing = map[byte]rune{
+	'`': '◆', 'a': '▒', 'f': '°', 'g': '±', 'j': '┘', 'k': '┐', 'l': '┌',
+	'm': '└', 'n': '┼', 'o': '⎺', 'p': '⎻', 'q': '─', 'r': '⎼', 's': '⎽',
+	't': '├', 'u': '┤', 'v': '┴', 'w': '┬', 'x': '│', 'y': '≤', 'z': '≥',
+	'{': 'π', '|': '≠', '}': '£', '~': '·',
+}
+
+// vtCursor is the part of the state saved by ESC 7 and restored by ESC 8
+type vtCursor struct {
+	x, y  int
+	style Style
+}
+
+// vtScreen emulates the screen of a VT100/xterm style terminal: it takes the
+// output of a program and keeps the grid of cells the program drew. Wide
+// runes are followed by a cell with no rune.
+type vtScreen struct {
+	width, height int
+	cells         [][]ColorRune
+	x, y          int
+	wrapPending   bool // the last column was written, the next rune wraps
+	style         Style
+	saved         vtCursor
+	top, bottom   int // the scroll region, inclusive
+
+	mainCells    [][]ColorRune // the main screen while the alternate one is shown
+	mainSaved    vtCursor
+	cursorHidden bool
+	autowrap     bool
+	appCursor    bool // the cursor keys send application sequences
+	lineDrawing  bool // the DEC special graphics charset is selected
+
+	state   int
+	params  []int
+	private byte   // the ? or > starting a control sequence's parameters
+	pending []byte // the start of a UTF-8 sequence split between writes
+	title   string
+	oscText []byte
+
+	// reply is given the answers to the program's queries, such as where the
+	// cursor is
+	reply func(answer []byte)
+}
+
+func newVtScreen(width, height int) *vtScreen {
+	s := &vtScreen{}
+	s.resize(width, height)
+	s.reset()
+	return s
+}
+
+// reset puts the terminal back in its initial state, keeping its size
+func (s *vtScreen) reset() {
+	s.cells = s.blankRows(s.height)
+	s.mainCells = nil
+	s.x, s.y, s.wrapPending = 0, 0, false
+	s.style = NewStyle()
+	s.saved = vtCursor{}
+	s.top, s.bottom = 0, s.height-1
+	s.cursorHidden, s.autowrap, s.appCursor, s.lineDrawing = false, true, false, false
+}
+
+// blank is an erased cell, which keeps the current background color
+func (s *vtScreen) blank() ColorRune {
+	return ColorRune{Ch: ' ', Style: NewStyle().Bg(s.style.Background())}
+}
+
+func (s *vtScreen) blankRow() []ColorRune {
+	row := make([]ColorRune, s.width)
+	s.erase(row)
+	return row
+}
+
+func (s *vtScreen) blankRows(count int) [][]ColorRune {
+	rows := make([][]ColorRune, count)
+	for idx := range rows {
+		rows[idx] = s.blankRow()
+	}
+	return rows
+}
+
+func (s *vtScreen) erase(cells []ColorRune) {
+	blank := s.blank()
+	for idx := range cells {
+		cells[idx] = blank
+	}
+}
+
+// resize changes the size of the screen. Rows are dropped from the top if
+// the screen gets too short for the cursor row.
+func (s *vtScreen) resize(width, height int) {
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	shift := s.y - (height - 1)
+	if shift < 0 {
+		shift = 0
+	}
+	s.cells = s.resizeRows(s.cells, width, height, shift)
+	if s.mainCells != nil {
+		s.mainCells = s.resizeRows(s.mainCells, width, height, shift)
+	}
+	s.width, s.height = width, height
+	s.y -= shift
+	s.x = clamp(s.x, 0, width-1)
+	s.wrapPending = false
+	s.top, s.bottom = 0, height-1
+}
+
+func (s *vtScreen) resizeRows(rows [][]ColorRune, width, height, shift int) [][]ColorRune {
+	resized := make([][]ColorRune, height)
+	for y := range resized {
+		resized[y] = make([]ColorRune, width)
+		var old []ColorRune
+		if y+shift < len(rows) {
+			old = rows[y+shift]
+		}
+		for x := range resized[y] {
+			if x < len(old) {
+				resized[y][x] = old[x]
+			} else {
+				resized[y][x] = ColorRune{Ch: ' '}
+			}
+		}
+	}
+	return resized
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// Write feeds program output to the terminal
+func (s *vtScreen) Write(data []byte) (int, error) {
+	for idx := 0; idx < len(data); idx++ {
+		b := data[idx]
+		if s.state != vtGround || b < 0x80 && len(s.pending) == 0 {
+			s.handleByte(b)
+			continue
+		}
+		// collect a UTF-8 sequence, which may continue in the next write
+		s.pending = append(s.pending, b)
+		if utf8.FullRune(s.pending) {
+			ch, size := utf8.DecodeRune(s.pending)
+			// the bytes after an invalid sequence are read again
+			rest := append([]byte(nil), s.pending[size:]...)
+			s.pending = s.pending[:0]
+			s.put(ch)
+			s.Write(rest)
+		}
+	}
+	return len(data), nil
+}
+
+func (s *vtScreen) handleByte(b byte) {
+	// control characters work in the middle of sequences too
+	if b < 0x20 && s.state != vtString && s.state != vtStringEscape {
+		s.control(b)
+		return
+	}
+	switch s.state {
+	case vtGround:
+		ch := rune(b)
+		if s.lineDrawing {
+			if drawn, ok := vtLineDrawing[b]; ok {
+				ch = drawn
+			}
+		}
+		if b != 0x7f {
+			s.put(ch)
+		}
+	case vtEscape:
+		s.escape(b)
+	case vtCharset:
+		s.lineDrawing = b == '0'
+		s.state = vtGround
+	case vtEscapeIgnore:
+		if b >= 0x30 {
+			s.state = vtGround
+		}
+	case vtCSI:
+		s.csiByte(b)
+	case vtString:
+		switch b {
+		case 0x07:
+			s.endString()
+		case 0x1b:
+			s.state = vtStringEscape
+		default:
+			s.oscText = append(s.oscText, b)
+		}
+	case vtStringEscape:
+		// ESC \ ends the string, anything else is ignored
+		s.endString()
+	}
+}
+
+// control handles the C0 control characters
+func (s *vtScreen) control(b byte) {
+	switch b {
+	case '\b':
+		s.moveTo(s.x-1, s.y)
+	case '\t':
+		x := (s.x/8 + 1) * 8
+		s.moveTo(x, s.y)
+	case '\n', '\v', '\f':
+		s.lineFeed()
+	case '\r':
+		s.moveTo(0, s.y)
+	case 0x1b:
+		s.state = vtEscape
+	case 0x18, 0x1a:
+		// CAN and SUB cancel a sequence
+		s.state = vtGround
+	}
+}
+
+func (s *vtScreen) escape(b byte) {
+	s.state = vtGround
+	switch b {
+	case '[':
+		s.state, s.params, s.private = vtCSI, s.params[:0], 0
+	case ']', 'P', '_', '^', 'X':
+		s.state, s.oscText = vtString, s.oscText[:0]
+	case '(':
+		s.state = vtCharset
+	case ')', '*', '+', '#', '%', ' ':
+		s.state = vtEscapeIgnore
+	case '7':
+		s.saved = vtCursor{x: s.x, y: s.y, style: s.style}
+	case '8':
+		s.style = s.saved.style
+		s.moveTo(s.saved.x, s.saved.y)
+	case 'D':
+		s.lineFeed()
+	case 'E':
+		s.moveTo(0, s.y)
+		s.lineFeed()
+	case 'M':
+		s.reverseIndex()
+	case 'c':
+		s.reset()
+	}
+}
+
+// endString finishes an OSC string, taking the window title from it
+func (s *vtScreen) endString() {
+	s.state = vtGround
+	text := string(s.oscText)
+	if strings.HasPrefix(text, "0;") || strings.HasPrefix(text, "2;") {
+		s.title = text[2:]
+	}
+}
+
+// put writes a rune at the cursor and moves the cursor past it
+func (s *vtScreen) put(ch rune) {
+	w := runewidth.RuneWidth(ch)
+	if w == 0 {
+		// combining marks aren't kept
+		return
+	}
+	if w > s.width {
+		return
+	}
+	if s.wrapPending || s.x+w > s.width {
+		if s.autowrap {
+			s.x = 0
+			s.lineFeed()
+		} else {
+			s.x = s.width - w
+		}
+	}
+	s.wrapPending = false
+	row := s.cells[s.y]
+	s.clearWide(row, s.x)
+	if w == 2 {
+		s.clearWide(row, s.x+1)
+	}
+	row[s.x] = ColorRune{Ch: ch, Style: s.style}
+	if w == 2 {
+		row[s.x+1] = ColorRune{Style: s.style}
+	}
+	s.x += w
+	if s.x >= s.width {
+		s.x = s.width - 1
+		s.wrapPending = true
+	}
+}
+
+// clearWide blanks the other half of a wide rune about to be overwritten
+func (s *vtScreen) clearWide(row []ColorRune, x int) {
+	if row[x].Ch == 0 && x > 0 {
+		row[x-1] = s.blank()
+	} else if x+1 < len(row) && row[x+1].Ch == 0 {
+		row[x+1] = s.blank()
+	}
+}
+
+func (s *vtScreen) moveTo(x, y int) {
+	s.x, s.y = clamp(x, 0, s.width-1), clamp(y, 0, s.height-1)
+	s.wrapPending = false
+}
+
+// lineFeed moves the cursor down, scrolling at the bottom of the scroll region
+func (s *vtScreen) lineFeed() {
+	s.wrapPending = false
+	if s.y == s.bottom {
+		s.scrollUp(s.top, s.bottom, 1)
+	} else if s.y < s.height-1 {
+		s.y++
+	}
+}
+
+// reverseIndex moves the cursor up, scrolling at the top of the scroll region
+func (s *vtScreen) reverseIndex() {
+	s.wrapPending = false
+	if s.y == s.top {
+		s.scrollDown(s.top, s.bottom, 1)
+	} else if s.y > 0 {
+		s.y--
+	}
+}
+
+// scrollUp moves the rows from top to bottom up, blanking the rows at the
+// bottom
+func (s *vtScreen) scrollUp(top, bottom, count int) {
+	count = clamp(count, 0, bottom-top+1)
+	copy(s.cells[top:bottom+1], s.cells[top+count:bottom+1])
+	for y := bottom - count + 1; y <= bottom; y++ {
+		s.cells[y] = s.blankRow()
+	}
+}
+
+// scrollDown moves the rows from top to bottom down, blanking the rows at the
+// top
+func (s *vtScreen) scrollDown(top, bottom, count int) {
+	count = clamp(count, 0, bottom-top+1)
+	copy(s.cells[top+count:bottom+1], s.cells[top:bottom+1-count])
+	for y := top; y < top+count; y++ {
+		s.cells[y] = s.blankRow()
+	}
+}
+
+// csiByte collects a control sequence's parameters until its final byte
+func (s *vtScreen) csiByte(b byte) {
+	switch {
+	case b >= '0' && b <= '9':
+		if len(s.params) == 0 {
+			s.params = append(s.params, 0)
+		}
+		last := len(s.params) - 1
+		if s.params[last] < 10000 {
+			s.params[last] = s.params[last]*10 + int(b-'0')
+		}
+	case b == ';' || b == ':':
+		if len(s.params) == 0 {
+			s.params = append(s.params, 0)
+		}
+		s.params = append(s.params, 0)
+	case b == '?' || b == '>' || b == '<' || b == '=':
+		s.private = b
+	case b >= 0x20 && b <= 0x2f:
+		// intermediate bytes aren't used by the sequences handled
+		s.private = b
+	case b >= 0x40 && b <= 0x7e:
+		s.state = vtGround
+		s.csi(b)
+	default:
+		s.state = vtGround
+	}
+}
+
+// param returns a parameter, or def if it's missing or zero
+func (s *vtScreen) param(idx, def int) int {
+	if idx >= len(s.params) || s.params[idx] == 0 {
+		return def
+	}
+	return s.params[idx]
+}
+
+// csi carries out a control sequence
+func (s *vtScreen) csi(final byte) {
+	if s.private == '?' {
+		switch final {
+		case 'h':
+			s.setModes(true)
+		case 'l':
+			s.setModes(false)
+		}
+		return
+	}
+	if s.private != 0 && !(s.private == '>' && final == 'c') {
+		return
+	}
+	n := s.param(0, 1)
+	switch final {
+	case 'A':
+		top := 0
+		if s.y >= s.top {
+			top = s.top
+		}
+		s.moveTo(s.x, clamp(s.y-n, top, s.height-1))
+	case 'B', 'e':
+		bottom := s.height - 1
+		if s.y <= s.bottom {
+			bottom = s.bottom
+		}
+		s.moveTo(s.x, clamp(s.y+n, 0, bottom))
+	case 'C', 'a':
+		s.moveTo(s.x+n, s.y)
+	case 'D':
+		s.moveTo(s.x-n, s.y)
+	case 'E':
+		s.moveTo(0, s.y+n)
+	case 'F':
+		s.moveTo(0, s.y-n)
+	case 'G', '`':
+		s.moveTo(n-1, s.y)
+	case 'd':
+		s.moveTo(s.x, n-1)
+	case 'H', 'f':
+		s.moveTo(s.param(1, 1)-1, n-1)
+	case 'J':
+		s.eraseDisplay(s.param(0, 0))
+	case 'K':
+		s.eraseLine(s.param(0, 0))
+	case '@':
+		row := s.cells[s.y]
+		n = clamp(n, 0, s.width-s.x)
+		copy(row[s.x+n:], row[s.x:])
+		s.erase(row[s.x : s.x+n])
+		s.wrapPending = false
+	case 'P':
+		row := s.cells[s.y]
+		n = clamp(n, 0, s.width-s.x)
+		copy(row[s.x:], row[s.x+n:])
+		s.erase(row[s.width-n:])
+		s.wrapPending = false
+	case 'X':
+		s.erase(s.cells[s.y][s.x:clamp(s.x+n, 0, s.width)])
+		s.wrapPending = false
+	case 'L':
+		if s.y >= s.top && s.y <= s.bottom {
+			s.scrollDown(s.y, s.bottom, n)
+			s.moveTo(0, s.y)
+		}
+	case 'M':
+		if s.y >= s.top && s.y <= s.bottom {
+			s.scrollUp(s.y, s.bottom, n)
+			s.moveTo(0, s.y)
+		}
+	case 'S':
+		s.scrollUp(s.top, s.bottom, n)
+	case 'T':
+		s.scrollDown(s.top, s.bottom, n)
+	case 'r':
+		top, bottom := s.param(0, 1)-1, s.param(1, s.height)-1
+		if top < bottom && bottom < s.height {
+			s.top, s.bottom = top, bottom
+			s.moveTo(0, 0)
+		}
+	case 'm':
+		s.sgr()
+	case 's':
+		s.saved = vtCursor{x: s.x, y: s.y, style: s.style}
+	case 'u':
+		s.style = s.saved.style
+		s.moveTo(s.saved.x, s.saved.y)
+	case 'n':
+		switch s.param(0, 0) {
+		case 5:
+			s.answer("\x1b[0n")
+		case 6:
+			s.answer(fmt.Sprintf("\x1b[%d;%dR", s.y+1, s.x+1))
+		}
+	case 'c':
+		if s.private == '>' {
+			s.answer("\x1b[>0;0;0c")
+		} else {
+			// a VT100 with advanced video
+			s.answer("\x1b[?1;2c")
+		}
+	}
+}
+
+func (s *vtScreen) answer(answer string) {
+	if s.reply != nil {
+		s.reply([]byte(answer))
+	}
+}
+
+func (s *vtScreen) eraseDisplay(mode int) {
+	switch mode {
+	case 0:
+		s.erase(s.cells[s.y][s.x:])
+		for _, row := range s.cells[s.y+1:] {
+			s.erase(row)
+		}
+	case 1:
+		s.erase(s.cells[s.y][:s.x+1])
+		for _, row := range s.cells[:s.y] {
+			s.erase(row)
+		}
+	case 2, 3:
+		for _, row := range s.cells {
+			s.erase(row)
+		}
+	}
+	s.wrapPending = false
+}
+
+func (s *vtScreen) eraseLine(mode int) {
+	row := s.cells[s.y]
+	switch mode {
+	case 0:
+		s.erase(row[s.x:])
+	case 1:
+		s.erase(row[:s.x+1])
+	case 2:
+		s.erase(row)
+	}
+	s.wrapPending = false
+}
+
+// setModes sets or resets the DEC private modes in the parameters
+func (s *vtScreen) setModes(on bool) {
+	for _, mode := range s.params {
+		switch mode {
+		case 1:
+			s.appCursor = on
+		case 7:
+			s.autowrap = on
+		case 25:
+			s.cursorHidden = !on
+		case 47, 1047:
+			s.alternateScreen(on)
+		case 1049:
+			// the cursor is saved along with the main screen
+			if on {
+				s.mainSaved = vtCursor{x: s.x, y: s.y, style: s.style}
+				s.alternateScreen(true)
+			} else if s.mainCells != nil {
+				s.alternateScreen(false)
+				s.style = s.mainSaved.style
+				s.moveTo(s.mainSaved.x, s.mainSaved.y)
+			}
+		}
+	}
+}
+
+// alternateScreen switches to a blank alternate screen, as full screen
+// programs do, or back to the main screen
+func (s *vtScreen) alternateScreen(on bool) {
+	if on && s.mainCells == nil {
+		s.mainCells = s.cells
+		s.cells = s.blankRows(s.height)
+	} else if !on && s.mainCells != nil {
+		s.cells = s.mainCells
+		s.mainCells = nil
+	}
+	s.wrapPending = false
+}
+
+// sgr sets the style runes are written in
+func (s *vtScreen) sgr() {
+	if len(s.params) == 0 {
+		s.style = NewStyle()
+		return
+	}
+	for idx := 0; idx < len(s.params); idx++ {
+		p := s.params[idx]
+		switch {
+		case p == 0:
+			s.style = NewStyle()
+		case p == 1:
+			s.style = s.style.Bold()
+		case p == 4:
+			s.style = s.style.Underline()
+		case p == 5 || p == 6:
+			s.style = s.style.Blink()
+		case p == 7:
+			s.style = s.style.Reverse()
+		case p == 22:
+			s.style.attrs &^= styleBold
+		case p == 24:
+			s.style.attrs &^= styleUnderline
+		case p == 25:
+			s.style.attrs &^= styleBlink
+		case p == 27:
+			s.style.attrs &^= styleReverse
+		case p >= 30 && p <= 37:
+			s.style = s.style.Fg(ColorBlack + TermColor(p-30))
+		case p == 38:
+			color, used := s.extendedColor(idx + 1)
+			idx += used
+			s.style = s.style.Fg(color)
+		case p == 39:
+			s.style = s.style.Fg(ColorDefault)
+		case p >= 40 && p <= 47:
+			s.style = s.style.Bg(ColorBlack + TermColor(p-40))
+		case p == 48:
+			color, used := s.extendedColor(idx + 1)
+			idx += used
+			s.style = s.style.Bg(color)
+		case p == 49:
+			s.style = s.style.Bg(ColorDefault)
+		case p >= 90 && p <= 97:
+			s.style = s.style.Fg(ColorDarkGray + TermColor(p-90))
+		case p >= 100 && p <= 107:
+			s.style = s.style.Bg(ColorDarkGray + TermColor(p-100))
+		}
+	}
+}
+
+// extendedColor reads the color of a 38 or 48 SGR parameter, 5;n for the 256
+// color palette or 2;r;g;b for RGB, returning how many parameters it used
+func (s *vtScreen) extendedColor(idx int) (TermColor, int) {
+	at := func(i int) uint8 {
+		if idx+i < len(s.params) {
+			return uint8(s.params[idx+i])
+		}
+		return 0
+	}
+	switch at(0) {
+	case 5:
+		return Color256(at(1)), 2
+	case 2:
+		return ColorRGB(at(1), at(2), at(3)), 4
+	}
+	return ColorDefault, 1
+}
+
+// draw draws the screen on a canvas
+func (s *vtScreen) draw(c *Canvas) {
+	for y, row := range s.cells {
+		for x, cell := range row {
+			// the cells wide runes spill into are left alone
+			if cell.Ch != 0 {
+				c.SetCell(x, y, cell.Ch, cell.Style)
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package gopanes
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// screenText returns the screen's rows with trailing spaces removed
+func screenText(s *vtScreen) []string {
+	var rows []string
+	for _, row := range s.cells {
+		var text strings.Builder
+		for _, cell := range row {
+			if cell.Ch != 0 {
+				text.WriteRune(cell.Ch)
+			}
+		}
+		rows = append(rows, strings.TrimRight(text.String(), " "))
+	}
+	return rows
+}
+
+func TestVtScreen(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{"wrap and scroll", "abcdefg\r\nhi\r\njk\r\nlm", []string{"efg", "hi", "jk", "lm"}},
+		{"pending wrap", "abcd\r\nx", []string{"abcd", "x", "", ""}},
+		{"cursor movement", "\x1b[3;2Hx\x1b[Ay\x1b[3D\x1b[Bz", []string{"", "  y", "zx", ""}},
+		{"erase", "abcd\r\nefgh\r\nijkl\x1b[2;3H\x1b[K\x1b[1J", []string{"", "", "ijkl", ""}},
+		{"erase below", "abcd\r\nefgh\r\nijkl\x1b[2;2H\x1b[J", []string{"abcd", "e", "", ""}},
+		{"insert and delete chars", "abcd\x1b[1;2H\x1b[2@\x1b[1;1H\x1b[P", []string{"  b", "", "", ""}},
+		{"scroll region", "1\r\n2\r\n3\r\n4\x1b[2;3r\x1b[3;1H\n", []string{"1", "3", "", "4"}},
+		{"insert lines", "1\r\n2\r\n3\r\n4\x1b[2;1H\x1b[L", []string{"1", "", "2", "3"}},
+		{"delete lines", "1\r\n2\r\n3\r\n4\x1b[2;1H\x1b[2M", []string{"1", "4", "", ""}},
+		{"reverse index", "1\r\n2\x1b[1;1H\x1bMx", []string{"x", "1", "2", ""}},
+		{"save and restore", "ab\x1b7\x1b[3;3Hc\x1b8d", []string{"abd", "", "  c", ""}},
+		{"tabs stop at the last column", "a\tb", []string{"a  b", "", "", ""}},
+		{"wide runes", "日本語", []string{"日本", "語", "", ""}},
+		{"split utf-8", "\xe6\x97", []string{"", "", "", ""}},
+		{"line drawing", "\x1b(0lqk\x1b(Bq", []string{"┌─┐q", "", "", ""}},
+		{"title", "\x1b]0;title\x07ok", []string{"ok", "", "", ""}},
+		{"alternate screen", "ma\x1b[?1049hfull\x1b[?1049lx", []string{"max", "", "", ""}},
+	}
+	for _, test := range tests {
+		s := newVtScreen(4, 4)
+		s.Write([]byte(test.output))
+		if got := screenText(s); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: screen = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestVtScreenSplitWrites(t *testing.T) {
+	s := newVtScreen(10, 2)
+	for _, b := range []byte("日\x1b[1;31mx\x1b]2;name\x1b\\") {
+		s.Write([]byte{b})
+	}
+	if got := screenText(s)[0]; got != "日x" {
+		t.Errorf("row = %q, want %q", got, "日x")
+	}
+	if s.title != "name" {
+		t.Errorf("title = %q, want %q", s.title, "name")
+	}
+	if want := NewStyle().Fg(ColorRed).Bold(); s.cells[0][2].Style != want {
+		t.Errorf("style = %v, want %v", s.cells[0][2].Style, want)
+	}
+}
+
+func TestVtScreenSGR(t *testing.T) {
+	tests := []struct {
+		sgr  string
+		want Style
+	}{
+		{"1;4;7", NewStyle().Bold().Underline().Reverse()},
+		{"1;22", NewStyle()},
+		{"32;44", NewStyle().Fg(ColorGreen).Bg(ColorBlue)},
+		{"91;103", NewStyle().Fg(ColorLightRed).Bg(ColorLightYellow)},
+		{"38;5;200;48;2;1;2;3", NewStyle().Fg(Color256(200)).Bg(ColorRGB(1, 2, 3))},
+		{"31;39", NewStyle()},
+		{"31;0", NewStyle()},
+		{"31;", NewStyle()},
+	}
+	for _, test := range tests {
+		s := newVtScreen(4, 1)
+		s.Write([]byte("\x1b[" + test.sgr + "mx"))
+		if got := s.cells[0][0].Style; got != test.want {
+			t.Errorf("SGR %s: style = %v, want %v", test.sgr, got, test.want)
+		}
+	}
+}
+
+func TestVtScreenReplies(t *testing.T) {
+	s := newVtScreen(10, 5)
+	var replies []string
+	s.reply = func(answer []byte) { replies = append(replies, string(answer)) }
+	s.Write([]byte("\x1b[3;4H\x1b[6n\x1b[c"))
+	want := []string{"\x1b[3;4R", "\x1b[?1;2c"}
+	if !reflect.DeepEqual(replies, want) {
+		t.Errorf("replies = %q, want %q", replies, want)
+	}
+}
+
+func TestVtScreenResize(t *testing.T) {
+	s := newVtScreen(4, 4)
+	s.Write([]byte("1\r\n2\r\n3\r\n4"))
+	s.resize(2, 2)
+	if want := []string{"3", "4"}; !reflect.DeepEqual(screenText(s), want) {
+		t.Errorf("screen = %q, want %q", screenText(s), want)
+	}
+	if s.x != 1 || s.y != 1 {
+		t.Errorf("cursor at %d,%d, want 1,1", s.x, s.y)
+	}
+	s.resize(3, 3)
+	s.Write([]byte("x\r\ny"))
+	if want := []string{"3", "4x", "y"}; !reflect.DeepEqual(screenText(s), want) {
+		t.Errorf("screen = %q, want %q", screenText(s), want)
+	}
+}
@@ -0,0 +1,235 @@
+package gopanes
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+type WatchOptions struct {
+	// how often to run again, defaults to two seconds
+	Interval time.Duration
+	// shows the lines that changed since the previous run in ChangeStyle,
+	// which defaults to reversed
+	HighlightChanges bool
+	ChangeStyle      Style
+}
+
+// Watcher reruns a command or callback on an interval, showing its latest
+// output in a pane like watch(1), see GoPane.Watch
+type Watcher struct {
+	lock     sync.Mutex
+	run      func() ([][]ColorStr, error)
+	opts     WatchOptions
+	paused   bool
+	previous []string // the text of the lines shown last time
+	lastRun  time.Time
+	wake     chan struct{} // runs again right away
+	// ends the watcher and kills the command it's running, if any
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stopped chan struct{}
+}
+
+// Watch calls run on an interval, replacing the pane's content with the lines
+// it returns each time. An error is shown in red after the lines. The prefix
+// key followed by p pauses and resumes the focused pane's watcher. It stops
+// when the pane is closed or the watcher is stopped.
+func (gp *GoPane) Watch(run func() ([][]ColorStr, error), opts WatchOptions) *Watcher {
+	return gp.startWatcher(newWatcher(run, opts))
+}
+
+// WatchCommand runs a command on an interval, showing its output like
+// `watch -n`, with stderr in red. command is called for every run, since an
+// exec.Cmd can only be run once. A run still going when the watcher is
+// stopped or the pane is closed is killed, along with any processes it
+// started. The pane is given the command line as its title if it has none.
+func (gp *GoPane) WatchCommand(command func() *exec.Cmd, opts WatchOptions) *Watcher {
+	w := newWatcher(nil, opts)
+	titled := gp.Title() != ""
+	w.run = func() ([][]ColorStr, error) {
+		cmd := command()
+		if !titled {
+			gp.SetTitle(strings.Join(cmd.Args, " "))
+			titled = true
+		}
+		return commandLines(w.ctx, cmd)
+	}
+	return gp.startWatcher(w)
+}
+
+func (gp *GoPane) startWatcher(w *Watcher) *Watcher {
+	gp.SetWatcher(w)
+	go w.loop(gp)
+	return w
+}
+
+// commandLines runs cmd to the end, returning its output lines
+func commandLines(ctx context.Context, cmd *exec.Cmd) ([][]ColorStr, error) {
+	var lines [][]ColorStr
+	run, err := startCommand(ctx, nil, cmd, func(line []ColorStr) {
+		lines = append(lines, line)
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = run.Wait()
+	// the exit status line is left out, the error is shown instead
+	return lines[:len(lines)-1], err
+}
+
+func newWatcher(run func() ([][]ColorStr, error), opts WatchOptions) *Watcher {
+	if opts.Interval <= 0 {
+		opts.Interval = 2 * time.Second
+	}
+	if opts.ChangeStyle == NewStyle() {
+		opts.ChangeStyle = NewStyle().Reverse()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Watcher{
+		run:     run,
+		opts:    opts,
+		wake:    make(chan struct{}, 1),
+		ctx:     ctx,
+		cancel:  cancel,
+		stopped: make(chan struct{}),
+	}
+}
+
+// SetWatcher records the watcher updating the pane, for the prefix commands
+func (gp *GoPane) SetWatcher(w *Watcher) {
+	if gp.isSplit() {
+		gp.First.SetWatcher(w)
+		return
+	}
+	gp.watcher = w
+}
+
+// Watcher returns the watcher updating the pane, if any
+func (gp *GoPane) Watcher() *Watcher {
+	if gp.isSplit() {
+		return nil
+	}
+	return gp.watcher
+}
+
+// Pause stops running until Resume is called, leaving the last output shown
+func (w *Watcher) Pause() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.paused = true
+	w.poke()
+}
+
+// Resume runs again right away and then on the interval
+func (w *Watcher) Resume() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.paused = false
+	w.poke()
+}
+
+// TogglePause pauses the watcher if it's running and resumes it otherwise
+func (w *Watcher) TogglePause() {
+	if w.IsPaused() {
+		w.Resume()
+	} else {
+		w.Pause()
+	}
+}
+
+func (w *Watcher) IsPaused() bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.paused
+}
+
+// poke wakes the loop up to run or show it's paused
+func (w *Watcher) poke() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Stop stops running and waits until the pane won't be changed any more
+func (w *Watcher) Stop() {
+	w.cancel()
+	<-w.stopped
+}
+
+func (w *Watcher) loop(gp *GoPane) {
+	defer close(w.stopped)
+	// closing the pane has to end a run that's still going too
+	go func() {
+		select {
+		case <-gp.Done():
+			w.cancel()
+		case <-w.ctx.Done():
+		}
+	}()
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+	for {
+		if !w.IsPaused() {
+			lines := w.update()
+			// a run cut short by stopping isn't shown
+			if w.ctx.Err() != nil {
+				return
+			}
+			gp.SetLines(lines)
+		}
+		gp.SetTitleStatus(w.status())
+		gp.Refresh()
+		select {
+		case <-ticker.C:
+		case <-w.wake:
+		case <-w.ctx.Done():
+			return
+		}
+	}
+}
+
+// update runs once, returning the lines to show with the changed ones
+// highlighted if asked to
+func (w *Watcher) update() [][]ColorStr {
+	lines, err := w.run()
+	if err != nil {
+		lines = append(lines, []ColorStr{Color.Red(err.Error())})
+	}
+	text := make([]string, len(lines))
+	for idx, line := range lines {
+		text[idx] = lineText(line)
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	// nothing has changed on the first run
+	if w.opts.HighlightChanges && w.previous != nil {
+		for idx, line := range lines {
+			if idx < len(w.previous) && w.previous[idx] == text[idx] {
+				continue
+			}
+			highlighted := make([]ColorStr, len(line))
+			for part, colorStr := range line {
+				highlighted[part] = ColorStr{Str: colorStr.Str, Style: inheritStyle(w.opts.ChangeStyle, colorStr.Style)}
+			}
+			lines[idx] = highlighted
+		}
+	}
+	w.previous = text
+	w.lastRun = time.Now()
+	return lines
+}
+
+// status describes the watcher for the pane's title
+func (w *Watcher) status() string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.paused {
+		return "paused"
+	}
+	return fmt.Sprintf("every %v, %s", w.opts.Interval, w.lastRun.Format("15:04:05"))
+}
@@ -0,0 +1,104 @@
+package gopanes
+
+import (
+	"errors"
+	"os/exec"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatcherHighlightsChanges(t *testing.T) {
+	outputs := [][]string{{"a", "b"}, {"a", "c", "d"}, {"a"}}
+	var err error
+	run := 0
+	w := newWatcher(func() ([][]ColorStr, error) {
+		var lines [][]ColorStr
+		for _, text := range outputs[run] {
+			lines = append(lines, []ColorStr{Color.Green(text)})
+		}
+		run++
+		return lines, err
+	}, WatchOptions{HighlightChanges: true})
+
+	changed := NewStyle().Fg(ColorGreen).Reverse()
+	if got, want := w.update(), [][]ColorStr{{Color.Green("a")}, {Color.Green("b")}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("first run = %v, want %v", got, want)
+	}
+	want := [][]ColorStr{{Color.Green("a")}, {changed.Str("c")}, {changed.Str("d")}}
+	if got := w.update(); !reflect.DeepEqual(got, want) {
+		t.Errorf("second run = %v, want %v", got, want)
+	}
+	err = errors.New("failed")
+	want = [][]ColorStr{{Color.Green("a")}, {NewStyle().Fg(ColorRed).Reverse().Str("failed")}}
+	if got := w.update(); !reflect.DeepEqual(got, want) {
+		t.Errorf("third run = %v, want %v", got, want)
+	}
+}
+
+func TestWatcherPause(t *testing.T) {
+	w := newWatcher(func() ([][]ColorStr, error) { return nil, nil }, WatchOptions{})
+	w.TogglePause()
+	if !w.IsPaused() || w.status() != "paused" {
+		t.Errorf("after pausing, IsPaused() = %v, status() = %q", w.IsPaused(), w.status())
+	}
+	w.TogglePause()
+	if w.IsPaused() {
+		t.Error("still paused after resuming")
+	}
+}
+
+// waitForLines waits until the pane has at least count lines
+func waitForLines(t *testing.T, gp *GoPane, count int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for gp.Len() < count {
+		if time.Now().After(deadline) {
+			t.Fatalf("the pane has %d lines, want %d", gp.Len(), count)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWatchCommand(t *testing.T) {
+	ui, _ := newTestUi(t, 40, 5)
+	gp := ui.Root
+	w := gp.WatchCommand(func() *exec.Cmd {
+		return exec.Command("sh", "-c", "echo out; exit 1")
+	}, WatchOptions{Interval: time.Hour})
+	defer w.Stop()
+	waitForLines(t, gp, 2)
+	log := gp.ContentLog()
+	want := [][]ColorStr{{Color.Default("out")}, {Color.Red("exit status 1")}}
+	if got := [][]ColorStr{log.Line(0), log.Line(1)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("lines = %v, want %v", got, want)
+	}
+	if got := gp.Title(); got != "sh -c echo out; exit 1" {
+		t.Errorf("title = %q", got)
+	}
+}
+
+func TestWatchCommandKilled(t *testing.T) {
+	ui, _ := newTestUi(t, 40, 5)
+	for _, stop := range []func(gp *GoPane, w *Watcher){
+		func(gp *GoPane, w *Watcher) { w.Stop() },
+		func(gp *GoPane, w *Watcher) { gp.Close() },
+	} {
+		gp := NewGoPane(40, 5, 0, 0)
+		gp.ui = ui
+		// cat holds the output open as long as sleep runs
+		w := gp.WatchCommand(func() *exec.Cmd {
+			return exec.Command("sh", "-c", "sleep 30 | cat")
+		}, WatchOptions{Interval: time.Hour})
+		time.Sleep(50 * time.Millisecond)
+		stop(gp, w)
+		select {
+		case <-w.stopped:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the watcher didn't stop while its command was running")
+		}
+		if gp.Len() != 0 {
+			t.Errorf("the killed run's output was shown: %v", gp.ContentLog().Line(0))
+		}
+	}
+}
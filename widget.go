@@ -0,0 +1,84 @@
+package gopanes
+
+import (
+	"github.com/nsf/termbox-go"
+)
+
+// A Widget is a component a leaf pane can host. Leaf panes start out hosting
+// a ContentLog, editable panes host an EditBox, and any other widget can be
+// put in a pane with SetWidget.
+type Widget interface {
+	// Draw draws the widget on a canvas covering the pane minus its header
+	// row
+	Draw(c *Canvas)
+	// HandleEvent handles a key event while the widget's pane is focused, or
+	// a mouse event over the pane. Mouse coordinates are relative to the
+	// widget's area. The pane is redrawn afterwards.
+	HandleEvent(ev termbox.Event)
+	// Focus and Blur are called when the widget's pane gains and loses focus
+	Focus()
+	Blur()
+	// PreferredSize is the size the widget would like to be drawn at, zero
+	// meaning it doesn't mind
+	PreferredSize() (width, height int)
+}
+
+// SetWidget puts a widget in the pane, replacing whatever it hosted before.
+// A split pane puts it in its first child. A nil widget goes back to an empty
+// ContentLog, since a leaf pane always hosts something.
+func (gp *GoPane) SetWidget(w Widget) {
+	if gp.isSplit() {
+		gp.First.SetWidget(w)
+		return
+	}
+	if w == nil {
+		w = NewContentLog()
+	}
+	if gp.widget != nil && gp.isFocused {
+		gp.widget.Blur()
+	}
+	gp.widget = w
+	if gp.isFocused {
+		w.Focus()
+	}
+}
+
+// Widget returns the widget the pane hosts, or nil for a split pane
+func (gp *GoPane) Widget() Widget {
+	if gp.isSplit() {
+		return nil
+	}
+	return gp.widget
+}
+
+// ContentLog returns the log the pane's content lines go to, or nil if the
+// pane hosts some other widget. A split pane returns its first child's.
+func (gp *GoPane) ContentLog() *ContentLog {
+	if gp.isSplit() {
+		return gp.First.ContentLog()
+	}
+	log, _ := gp.widget.(*ContentLog)
+	return log
+}
+
+// editBox returns the pane's EditBox, or nil if it isn't editable
+func (gp *GoPane) editBox() *EditBox {
+	if gp.isSplit() {
+		return nil
+	}
+	eb, _ := gp.widget.(*EditBox)
+	return eb
+}
+
+// dimArea adds the dim attribute to everything already drawn in the area
+func dimArea(x, y, width, height int) {
+	cells := drawScreen.CellBuffer()
+	screenWidth, screenHeight := drawScreen.Size()
+	for row := y; row < y+height && row < screenHeight; row++ {
+		for col := x; col < x+width && col < screenWidth; col++ {
+			if row >= 0 && col >= 0 {
+				cells[row*screenWidth+col].Fg |= termbox.AttrDim
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package gopanes
+
+import (
+	"github.com/nsf/termbox-go"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// recordingWidget records what the pane asks of it
+type recordingWidget struct {
+	calls  []string
+	canvas [4]int // the area it was last drawn in
+	events []termbox.Event
+}
+
+func (rw *recordingWidget) Draw(c *Canvas) {
+	rw.canvas = [4]int{c.x, c.y, c.Width(), c.Height()}
+	c.Print(0, 0, "widget", NewStyle())
+}
+func (rw *recordingWidget) HandleEvent(ev termbox.Event)       { rw.events = append(rw.events, ev) }
+func (rw *recordingWidget) Focus()                             { rw.calls = append(rw.calls, "focus") }
+func (rw *recordingWidget) Blur()                              { rw.calls = append(rw.calls, "blur") }
+func (rw *recordingWidget) PreferredSize() (width, height int) { return 0, 0 }
+
+func TestSetWidget(t *testing.T) {
+	ui, ms := newTestUi(t, 10, 4)
+	pane := ui.Root
+	pane.SetTitle("t")
+	ui.FocusPane(pane)
+	first, second := &recordingWidget{}, &recordingWidget{}
+	pane.SetWidget(first)
+	pane.SetWidget(second)
+	if want := []string{"focus", "blur"}; !reflect.DeepEqual(first.calls, want) {
+		t.Errorf("first widget calls = %q, want %q", first.calls, want)
+	}
+	if want := []string{"focus"}; !reflect.DeepEqual(second.calls, want) {
+		t.Errorf("second widget calls = %q, want %q", second.calls, want)
+	}
+
+	// the widget is drawn below the title and gets mouse events relative to
+	// its area
+	pane.HandleEvent(termbox.Event{Type: termbox.EventMouse, Key: termbox.MouseLeft, MouseX: 3, MouseY: 2})
+	if want := [4]int{0, 1, 10, 3}; second.canvas != want {
+		t.Errorf("drawn in %v, want %v", second.canvas, want)
+	}
+	if got := screenRows(ms)[1]; got != "widget    " {
+		t.Errorf("row under the title = %q", got)
+	}
+	if len(second.events) != 1 || second.events[0].MouseX != 3 || second.events[0].MouseY != 1 {
+		t.Errorf("events = %v", second.events)
+	}
+
+	// a nil widget puts an empty log back rather than leaving nothing to draw
+	pane.SetWidget(nil)
+	if pane.ContentLog() == nil {
+		t.Fatal("no content log after SetWidget(nil)")
+	}
+	ui.FocusPane(pane)
+	pane.Refresh()
+}
+
+func TestEditBoxWidget(t *testing.T) {
+	ui, ms := newTestUi(t, 10, 2)
+	pane := ui.Root
+	pane.MakeEditable()
+	ui.FocusPane(pane)
+	for _, ch := range "hi" {
+		pane.HandleEvent(termbox.Event{Type: termbox.EventKey, Ch: ch})
+	}
+	if got := screenRows(ms)[0]; got != "hi        " {
+		t.Errorf("edit box row = %q", got)
+	}
+	lines := make(chan string, 1)
+	go func() { lines <- pane.GetLine() }()
+	pane.HandleKey(termbox.KeyEnter)
+	select {
+	case line := <-lines:
+		if line != "hi" {
+			t.Errorf("GetLine() = %q, want %q", line, "hi")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the line wasn't submitted")
+	}
+}
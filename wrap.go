@@ -0,0 +1,172 @@
+package gopanes
+
+import (
+	"github.com/mattn/go-runewidth"
+	"strconv"
+)
+
+// WrapMode is how a pane fits lines that are wider than it is
+type WrapMode int
+
+const (
+	WrapChar WrapMode = iota // break lines at the last character that fits
+	WrapWord                 // break lines at spaces where possible
+	WrapNone                 // don't break lines, truncate and scroll horizontally
+)
+
+// SetWrapMode changes how the log's lines are wrapped. A log that doesn't
+// wrap scrolls sideways with the left and right arrow keys, and Home scrolls
+// back to the start of the rows. The mouse wheel only scrolls up and down,
+// since termbox doesn't report modifier keys held while scrolling it.
+func (cl *ContentLog) SetWrapMode(mode WrapMode) {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	cl.wrapMode = mode
+	cl.hScroll = 0
+}
+
+// ShowContinuation marks the rows that continue a wrapped line
+func (cl *ContentLog) ShowContinuation(show bool) {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	cl.showContinuation = show
+}
+
+// ScrollLeft scrolls the content of a log that doesn't wrap to the left
+func (cl *ContentLog) ScrollLeft(cols int) {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	cl.hScroll -= cols
+	if cl.hScroll < 0 {
+		cl.hScroll = 0
+	}
+}
+
+// ScrollRight scrolls the content of a log that doesn't wrap to the right
+func (cl *ContentLog) ScrollRight(cols int) {
+	cl.contentLock.Lock()
+	defer cl.contentLock.Unlock()
+	if cl.wrapMode != WrapNone {
+		return
+	}
+	maxScroll := 0
+	for _, row := range cl.wrapRows(cl.width) {
+		if w := rowWidth(row) - cl.width; w > maxScroll {
+			maxScroll = w
+		}
+	}
+	cl.hScroll += cols
+	if cl.hScroll > maxScroll {
+		cl.hScroll = maxScroll
+	}
+}
+
+func (gp *GoPane) SetWrapMode(mode WrapMode) {
+	if log := gp.ContentLog(); log != nil {
+		log.SetWrapMode(mode)
+	}
+}
+
+func (gp *GoPane) ShowContinuation(show bool) {
+	if log := gp.ContentLog(); log != nil {
+		log.ShowContinuation(show)
+	}
+}
+
+// runeWidth returns how many cells a rune takes up, with tabs taking up to
+// the next tabstop and unprintable runes taking none
+func runeWidth(r rune, col int) int {
+	if r == '\t' {
+		return tabstop_length - col%tabstop_length
+	}
+	if !strconv.IsPrint(r) {
+		return 0
+	}
+	return runewidth.RuneWidth(r)
+}
+
+// rowWidth returns how many cells a wrapped row takes up
+func rowWidth(row []ColorRune) int {
+	width := 0
+	for _, colorRune := range row {
+		width += runeWidth(colorRune.Ch, width)
+	}
+	return width
+}
+
+// wrapLine splits a line into rows no wider than width. Newlines always start
+// a new row. Continuation rows start with the marker unless it is 0.
+func wrapLine(line []ColorStr, width int, mode WrapMode, marker rune) [][]ColorRune {
+	rows := [][]ColorRune{nil}
+	col := 0
+	// where the current row could be broken for word wrapping
+	breakAt := -1
+	// whether the current row continues a wrapped line and has nothing but
+	// the marker yet
+	continuing := false
+	newRow := func() {
+		rows = append(rows, nil)
+		col = 0
+		breakAt = -1
+		continuing = true
+		if marker != 0 {
+			rows[len(rows)-1] = append(rows[len(rows)-1], ColorRune{Ch: marker})
+			col = runewidth.RuneWidth(marker)
+		}
+	}
+	for _, colorStr := range line {
+		for _, r := range colorStr.Str {
+			if r == '\n' {
+				rows = append(rows, nil)
+				col, breakAt, continuing = 0, -1, false
+				continue
+			}
+			w := runeWidth(r, col)
+			if r != '\t' && w == 0 {
+				continue
+			}
+			if r == '\t' {
+				// tabs become spaces, which wrap and break words like any
+				// other space
+				for i := 0; i < w; i++ {
+					if mode != WrapNone && col >= width && col > 0 {
+						newRow()
+					}
+					if mode == WrapWord && continuing {
+						continue
+					}
+					rows[len(rows)-1] = append(rows[len(rows)-1], ColorRune{Ch: ' ', Style: colorStr.Style})
+					col++
+					breakAt = len(rows[len(rows)-1])
+					continuing = false
+				}
+				continue
+			}
+			if mode != WrapNone && col+w > width && col > 0 {
+				row := rows[len(rows)-1]
+				if mode == WrapWord && breakAt > 0 && breakAt < len(row) {
+					// move the partial word down to the next row
+					rows[len(rows)-1] = row[:breakAt]
+					tail := append([]ColorRune(nil), row[breakAt:]...)
+					newRow()
+					rows[len(rows)-1] = append(rows[len(rows)-1], tail...)
+					col = rowWidth(rows[len(rows)-1])
+					continuing = false
+				} else {
+					newRow()
+				}
+			}
+			// the space a word wrapped line broke on isn't carried over
+			if mode == WrapWord && r == ' ' && continuing {
+				continue
+			}
+			continuing = false
+			rows[len(rows)-1] = append(rows[len(rows)-1], ColorRune{Ch: r, Style: colorStr.Style})
+			col += w
+			if r == ' ' {
+				breakAt = len(rows[len(rows)-1])
+			}
+		}
+	}
+	return rows
+}
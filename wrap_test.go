@@ -0,0 +1,116 @@
+package gopanes
+
+import (
+	"testing"
+)
+
+func rowStrings(rows [][]ColorRune) []string {
+	var strs []string
+	for _, row := range rows {
+		var runes []rune
+		for _, colorRune := range row {
+			runes = append(runes, colorRune.Ch)
+		}
+		strs = append(strs, string(runes))
+	}
+	return strs
+}
+
+func TestWrapLine(t *testing.T) {
+	line := []ColorStr{Color.Default("the quick "), Color.Red("brown fox")}
+	tests := []struct {
+		name   string
+		mode   WrapMode
+		marker rune
+		want   []string
+	}{
+		{"char", WrapChar, 0, []string{"the quic", "k brown ", "fox"}},
+		{"word", WrapWord, 0, []string{"the ", "quick ", "brown ", "fox"}},
+		{"none", WrapNone, 0, []string{"the quick brown fox"}},
+		{"marker", WrapChar, '>', []string{"the quic", ">k brown", "> fox"}},
+	}
+	for _, test := range tests {
+		got := rowStrings(wrapLine(line, 8, test.mode, test.marker))
+		if len(got) != len(test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestWrapLineKeepsStyles(t *testing.T) {
+	rows := wrapLine([]ColorStr{Color.Default("ab"), Color.Red("cd")}, 3, WrapChar, 0)
+	if len(rows) != 2 || rows[1][0].Ch != 'd' || rows[1][0].Style.Foreground() != ColorRed {
+		t.Errorf("unexpected rows %+v", rows)
+	}
+}
+
+func TestWrapLineWideRunesAndNewlines(t *testing.T) {
+	got := rowStrings(wrapLine([]ColorStr{Color.Default("日本語\nab")}, 4, WrapChar, 0))
+	want := []string{"日本", "語", "ab"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWrapWordTrimsBreakingSpace(t *testing.T) {
+	tests := []struct {
+		marker rune
+		want   []string
+	}{
+		{0, []string{"abcd", "efgh", "ij"}},
+		{'>', []string{"abcd", ">efg", ">h ", ">ij"}},
+	}
+	for _, test := range tests {
+		got := rowStrings(wrapLine([]ColorStr{Color.Default("abcd efgh ij")}, 4, WrapWord, test.marker))
+		if len(got) != len(test.want) {
+			t.Errorf("marker %q: got %q, want %q", test.marker, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("marker %q: got %q, want %q", test.marker, got, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestWrapLineTabs(t *testing.T) {
+	line := []ColorStr{Color.Default("abcdef\tgh")}
+	tests := []struct {
+		name string
+		mode WrapMode
+		want []string
+	}{
+		// the tab takes the row to the tabstop at 8, past the width of 7
+		{"char", WrapChar, []string{"abcdef ", " gh"}},
+		{"word", WrapWord, []string{"abcdef ", "gh"}},
+		{"none", WrapNone, []string{"abcdef  gh"}},
+	}
+	for _, test := range tests {
+		rows := wrapLine(line, 7, test.mode, 0)
+		got := rowStrings(rows)
+		if len(got) != len(test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] || rowWidth(rows[i]) > 7 && test.mode != WrapNone {
+				t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+				break
+			}
+		}
+	}
+	// word wrapping breaks at a tab
+	got := rowStrings(wrapLine([]ColorStr{Color.Default("ab\tcdefghij")}, 10, WrapWord, 0))
+	if len(got) != 2 || got[0] != "ab      " || got[1] != "cdefghij" {
+		t.Errorf("word wrapping after a tab: got %q", got)
+	}
+}